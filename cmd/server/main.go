@@ -2,22 +2,39 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"syscall"
 	"time"
 
 	"echo-server/internal/config"
+	"echo-server/internal/counter"
+	"echo-server/internal/har"
+	"echo-server/internal/namespace"
+	"echo-server/internal/openapi"
 	"echo-server/internal/server"
+	"echo-server/internal/tracing"
 	"echo-server/pkg/logger"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		}
+	}
+
 	cfg := getConfig()
 
 	if cfg == nil {
@@ -28,6 +45,32 @@ func main() {
 	cm := config.NewConfigManager()
 	cm.UpdateConfig(cfg)
 
+	// Export spans when tracing is configured
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Tracing != nil {
+		var err error
+		shutdownTracing, err = tracing.Setup(context.Background(), cfg.Tracing)
+		if err != nil {
+			logger.Error("Failed to set up tracing: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// Restore counters from the last snapshot and keep saving them
+	var snapshotter *counter.Snapshotter
+	if cfg.CounterSnapshot != nil && cfg.CounterSnapshot.File != "" {
+		snapshotter = counter.NewSnapshotter(counter.GetGlobalCounter(), cfg.CounterSnapshot.File, cfg.CounterSnapshot.SnapshotInterval())
+		snapshotter.IncludeNamespaces(namespace.Default())
+		if err := snapshotter.Restore(); err != nil {
+			logger.Error("Failed to restore counter snapshot: %v", err)
+			os.Exit(1)
+		}
+		snapshotter.Start()
+	}
+
+	// Remove namespaces that outlived their TTL
+	stopCleanup := namespace.Default().StartCleanup(namespace.CleanupInterval)
+
 	// Create and start server
 	srv := server.New(cm)
 
@@ -51,10 +94,149 @@ func main() {
 	if err := srv.Stop(ctx); err != nil {
 		logger.Error("Server forced to shutdown: %v", err)
 	}
+	stopCleanup()
+	if snapshotter != nil {
+		if err := snapshotter.Stop(); err != nil {
+			logger.Error("Failed to save counter snapshot: %v", err)
+		}
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces: %v", err)
+	}
 
 	logger.Info("Server exiting")
 }
 
+// runValidate checks path config directories the way CI would: every
+// problem is printed as file: config: field: message and the exit code is 1
+// when any were found
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: echo-server validate [-json] <paths-dir>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	valid := true
+	for _, dir := range fs.Args() {
+		report, err := config.ValidateDir(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", dir, err)
+			return 2
+		}
+		valid = valid && report.Valid
+
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(report)
+			continue
+		}
+		for _, e := range report.Errors {
+			fmt.Println(e.Error())
+		}
+		fmt.Printf("%s: %d configs, %d problems\n", dir, report.Configs, len(report.Errors))
+	}
+
+	if !valid {
+		return 1
+	}
+	return 0
+}
+
+// runImport converts an API description or recorded traffic into path
+// configs, printed as a JSON array or written to a directory one file per
+// config
+func runImport(args []string) int {
+	usage := "Usage: echo-server import openapi [-out dir] [-base-path path] <spec.yaml>\n" +
+		"       echo-server import har [-out dir] [-host host] <file.har>"
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("import "+args[0], flag.ExitOnError)
+	outDir := fs.String("out", "", "Write one path config file per config to this directory")
+	var basePath, host *string
+	switch args[0] {
+	case "openapi":
+		basePath = fs.String("base-path", "", "Prefix for every path (default: path of the first server URL)")
+	case "har":
+		host = fs.String("host", "", "Only import requests sent to this host")
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	var configs []config.PathConfig
+	if args[0] == "openapi" {
+		configs, err = openapi.Import(context.Background(), data, openapi.ImportOptions{BasePath: *basePath})
+	} else {
+		configs, err = har.Import(data, har.ImportOptions{Host: *host})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+
+	if *outDir == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(configs); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for i, pc := range configs {
+		data, err := json.MarshalIndent(pc, "", "    ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		// The index keeps the load order, concrete paths before templated ones
+		file := filepath.Join(*outDir, fmt.Sprintf("%03d-%s.json", i+1, fileName(pc.Name)))
+		if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(file)
+	}
+	return 0
+}
+
+// fileName turns a config name into a safe file name
+func fileName(name string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(name, "-"), "-")
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
 func getConfig() *config.ServerConfig {
 	// Define command-line flags
 	host := flag.String("host", "0.0.0.0", "Server host (overrides config file)")
@@ -64,6 +246,19 @@ func getConfig() *config.ServerConfig {
 	configPath := flag.String("config", "config/server.json", "Path to server configuration file")
 	pathsDir := flag.String("paths-dir", "config/paths", "Path to directory containing path configurations")
 	logLevel := flag.String("log-level", "info", "Logging level (debug, info, warn, error)")
+	logFormat := flag.String("log-format", "text", "Log output format (text, json, logfmt)")
+	unixSocket := flag.String("unix-socket", "", "Also serve on this Unix socket path")
+	socketMode := flag.String("socket-mode", "", "Octal file mode for the Unix socket (e.g. 0660)")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file (enables HTTPS)")
+	tlsKey := flag.String("tls-key", "", "TLS private key file")
+	tlsSelfSigned := flag.Bool("tls-self-signed", false, "Serve HTTPS with a certificate generated at startup")
+	tlsClientAuth := flag.String("tls-client-auth", "", "Client certificate policy (none, request, require, verify, require-verify)")
+	tlsClientCA := flag.String("tls-client-ca", "", "CA file used to verify client certificates")
+	strict := flag.Bool("strict", false, "Answer unmatched requests with 404 and the closest path configs")
+	validateRequests := flag.String("validate-requests", "", "Reject requests violating this OpenAPI document with 400")
+	adminPrefix := flag.String("admin-prefix", "", "Serve admin endpoints below this path (e.g. /__admin)")
+	adminPort := flag.Int("admin-port", 0, "Serve admin endpoints only on this port")
+	adminToken := flag.String("admin-token", os.Getenv("ECHO_SERVER_ADMIN_TOKEN"), "Bearer token required by admin endpoints")
 	help := flag.Bool("help", false, "Show help message")
 
 	flag.Parse()
@@ -72,16 +267,13 @@ func getConfig() *config.ServerConfig {
 		return nil
 	}
 
-	// Set log level
-	switch strings.ToLower(*logLevel) {
-	case "debug":
-		logger.SetLevel(logger.DEBUG)
-	case "info":
-		logger.SetLevel(logger.INFO)
-	case "warn":
-		logger.SetLevel(logger.WARN)
-	case "error":
-		logger.SetLevel(logger.ERROR)
+	// Set log level and format
+	if level, err := logger.ParseLevel(*logLevel); err == nil {
+		logger.SetLevel(level)
+	}
+	if err := logger.SetFormat(*logFormat, os.Stdout); err != nil {
+		logger.Error("Invalid log format: %v", err)
+		os.Exit(1)
 	}
 
 	// Initialize configuration loader
@@ -122,12 +314,74 @@ func getConfig() *config.ServerConfig {
 	if *writeTimeout != 0 {
 		cfg.WriteTimeout.Duration = *writeTimeout
 	}
+	if *unixSocket != "" {
+		cfg.UnixSocket = *unixSocket
+	}
+	if *socketMode != "" {
+		cfg.SocketMode = *socketMode
+	}
+	// TLS flags only override the server config file when given explicitly
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["tls-cert"] || set["tls-key"] || set["tls-self-signed"] {
+		if cfg.TLS == nil {
+			cfg.TLS = &config.TLSConfig{}
+		}
+		if set["tls-cert"] {
+			cfg.TLS.CertFile = *tlsCert
+		}
+		if set["tls-key"] {
+			cfg.TLS.KeyFile = *tlsKey
+		}
+		if set["tls-self-signed"] {
+			cfg.TLS.SelfSigned = *tlsSelfSigned
+		}
+	}
+	if set["tls-client-auth"] || set["tls-client-ca"] {
+		if cfg.TLS == nil {
+			logger.Error("-tls-client-auth and -tls-client-ca need TLS, set -tls-cert and -tls-key or -tls-self-signed")
+			os.Exit(1)
+		}
+		if set["tls-client-auth"] {
+			cfg.TLS.ClientAuth = *tlsClientAuth
+		}
+		if set["tls-client-ca"] {
+			cfg.TLS.ClientCAFile = *tlsClientCA
+		}
+	}
+
+	if *strict {
+		cfg.Strict = true
+	}
+	if *validateRequests != "" {
+		cfg.RequestValidation = &config.RequestValidationConfig{Spec: *validateRequests}
+	}
+	if *adminPrefix != "" || *adminPort != 0 || *adminToken != "" {
+		if cfg.Admin == nil {
+			cfg.Admin = &config.AdminConfig{}
+		}
+		if *adminPrefix != "" {
+			cfg.Admin.Prefix = *adminPrefix
+		}
+		if *adminPort != 0 {
+			cfg.Admin.Port = *adminPort
+		}
+		if *adminToken != "" {
+			cfg.Admin.Token = *adminToken
+		}
+	}
 
 	// Load path configurations
 	if err := loader.LoadPathConfigs(configPathRoutes); err != nil {
 		logger.Error("Failed to load path configs: %v", err)
 		os.Exit(1)
 	}
+
+	// Load path configurations owned by additional listeners
+	if err := loader.LoadListenerConfigs(); err != nil {
+		logger.Error("Failed to load listener configs: %v", err)
+		os.Exit(1)
+	}
 	return cfg
 }
 
@@ -135,12 +389,42 @@ const helpText = `Echo Server - A configurable HTTP mock server
 
 Usage:
   echo-server [options]
+  echo-server validate [-json] <paths-dir>...
+  echo-server import openapi [-out dir] [-base-path path] <spec.yaml>
+  echo-server import har [-out dir] [-host host] <file.har>
 
 Options:
   -port int
         Port to run the server on (default 8080)
   -config string
         Path to configuration directory (default "./config")
+  -unix-socket string
+        Also serve on a Unix socket, removed again on shutdown
+  -socket-mode string
+        Octal file mode for the Unix socket (e.g. 0660)
+  -log-level string
+        Logging level: debug, info, warn, error (default "info")
+  -log-format string
+        Log output format: text, json, logfmt (default "text")
+  -tls-cert string, -tls-key string
+        Serve HTTPS with the given certificate and key
+  -tls-self-signed
+        Serve HTTPS with a generated certificate, the CA is written to echo-server-ca.pem
+  -tls-client-auth string
+        Client certificate policy: none, request, require, verify, require-verify
+  -tls-client-ca string
+        CA file used to verify client certificates (mutual TLS)
+        Both need TLS from the config file or the flags above
+  -strict
+        Answer requests no path config matches with 404 and the closest configs
+  -validate-requests string
+        Reject requests violating this OpenAPI document with 400 and a report
+  -admin-prefix string
+        Serve the admin endpoints (/config, /counter, /ui, ...) below this path (e.g. /__admin)
+  -admin-port int
+        Serve the admin endpoints only on this port
+  -admin-token string
+        Bearer token required by the admin endpoints (default $ECHO_SERVER_ADMIN_TOKEN)
   -help
         Show this help message
 
@@ -154,6 +438,18 @@ Examples:
   # Use custom config directory
   echo-server -config /path/to/configs
 
+  # Also listen on a Unix socket readable by the group
+  echo-server -unix-socket /tmp/echo.sock -socket-mode 0660
+
+  # Serve HTTPS requiring client certificates signed by ca.pem
+  echo-server -tls-self-signed -tls-client-auth require-verify -tls-client-ca ca.pem
+
+  # Enforce the contract of an API description
+  echo-server -validate-requests petstore.yaml
+
+  # Keep the admin API off the mocked port and require a token
+  ECHO_SERVER_ADMIN_TOKEN=s3cret echo-server -admin-port 9090
+
   # Show help
   echo-server -help
 
@@ -165,5 +461,8 @@ Features:
   - Request counters
   - Path pattern matching
   - Multiple HTTP methods support
+  - TLS and mutual TLS
+  - Multiple listeners impersonating several services
+  - OpenTelemetry tracing with W3C trace context propagation
 
 For more information, visit: https://github.com/anmaso/echo-server-go`
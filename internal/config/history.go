@@ -0,0 +1,205 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHistoryLimit is the number of versions kept when no limit is
+// configured
+const DefaultHistoryLimit = 100
+
+// Kinds of config changes
+const (
+	ChangeAdded   = "added"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// ConfigChange is a path config added, updated or deleted by a version.
+// Fields lists the JSON fields an update changed.
+type ConfigChange struct {
+	Op     string      `json:"op"`
+	Name   string      `json:"name"`
+	Fields []string    `json:"fields,omitempty"`
+	Before *PathConfig `json:"before,omitempty"`
+	After  *PathConfig `json:"after,omitempty"`
+}
+
+// ConfigVersion is the set of path configs after a change: who made it,
+// through what and when, and how it differs from the previous version
+type ConfigVersion struct {
+	Version int            `json:"version"`
+	Time    time.Time      `json:"time"`
+	Author  string         `json:"author,omitempty"`
+	Source  string         `json:"source"`
+	Configs int            `json:"configs"`
+	Changes []ConfigChange `json:"changes"`
+	configs []PathConfig
+}
+
+// PathConfigs returns the path configs of the version
+func (v ConfigVersion) PathConfigs() []PathConfig {
+	return append([]PathConfig(nil), v.configs...)
+}
+
+// History keeps the most recent versions of the path configs up to its
+// limit, older versions are dropped. A nil History records nothing.
+type History struct {
+	mu       sync.Mutex
+	versions []ConfigVersion
+	last     int
+	limit    int
+}
+
+// NewHistory creates an empty history keeping up to limit versions,
+// DefaultHistoryLimit when limit is not positive
+func NewHistory(limit int) *History {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	return &History{limit: limit}
+}
+
+// Record adds a version for a change from before to after. Nothing is
+// recorded when the configs did not change.
+func (h *History) Record(author, source string, before, after []PathConfig) (ConfigVersion, bool) {
+	if h == nil {
+		return ConfigVersion{}, false
+	}
+	changes, changed := DiffPathConfigs(before, after)
+	if !changed {
+		return ConfigVersion{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.last++
+	v := ConfigVersion{
+		Version: h.last,
+		Time:    time.Now().UTC(),
+		Author:  author,
+		Source:  source,
+		Configs: len(after),
+		Changes: changes,
+		configs: append([]PathConfig(nil), after...),
+	}
+	h.versions = append(h.versions, v)
+	if len(h.versions) > h.limit {
+		h.versions = append([]ConfigVersion(nil), h.versions[len(h.versions)-h.limit:]...)
+	}
+	return v, true
+}
+
+// Versions returns the kept versions, oldest first
+func (h *History) Versions() []ConfigVersion {
+	if h == nil {
+		return []ConfigVersion{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]ConfigVersion{}, h.versions...)
+}
+
+// Version returns the version numbered n if it is still kept
+func (h *History) Version(n int) (ConfigVersion, bool) {
+	if h == nil {
+		return ConfigVersion{}, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, v := range h.versions {
+		if v.Version == n {
+			return v, true
+		}
+	}
+	return ConfigVersion{}, false
+}
+
+// DiffPathConfigs compares two sets of path configs by name, or pattern for
+// unnamed configs. It also reports a change when only the order differs,
+// which changes what requests match.
+func DiffPathConfigs(before, after []PathConfig) ([]ConfigChange, bool) {
+	beforeJSON := encodeConfigs(before)
+	afterJSON := encodeConfigs(after)
+	if reflect.DeepEqual(beforeJSON, afterJSON) {
+		return nil, false
+	}
+
+	// Configs sharing a key are paired by occurrence
+	type slot struct {
+		key string
+		n   int
+	}
+	slots := func(configs []PathConfig) []slot {
+		seen := make(map[string]int)
+		result := make([]slot, len(configs))
+		for i := range configs {
+			key := configs[i].CounterKey()
+			result[i] = slot{key, seen[key]}
+			seen[key]++
+		}
+		return result
+	}
+	beforeSlots, afterSlots := slots(before), slots(after)
+	beforeIndex := make(map[slot]int)
+	for i, s := range beforeSlots {
+		beforeIndex[s] = i
+	}
+
+	changes := []ConfigChange{}
+	matched := make(map[int]bool)
+	for i, s := range afterSlots {
+		j, ok := beforeIndex[s]
+		if !ok {
+			changes = append(changes, ConfigChange{Op: ChangeAdded, Name: s.key, After: &after[i]})
+			continue
+		}
+		matched[j] = true
+		if fields := changedFields(beforeJSON[j], afterJSON[i]); len(fields) > 0 {
+			changes = append(changes, ConfigChange{Op: ChangeUpdated, Name: s.key, Fields: fields, Before: &before[j], After: &after[i]})
+		}
+	}
+	for j, s := range beforeSlots {
+		if !matched[j] {
+			changes = append(changes, ConfigChange{Op: ChangeDeleted, Name: s.key, Before: &before[j]})
+		}
+	}
+	return changes, true
+}
+
+// encodeConfigs decodes every config into its top level JSON fields
+func encodeConfigs(configs []PathConfig) []map[string]json.RawMessage {
+	result := make([]map[string]json.RawMessage, len(configs))
+	for i := range configs {
+		data, err := json.Marshal(&configs[i])
+		if err == nil {
+			err = json.Unmarshal(data, &result[i])
+		}
+		if err != nil {
+			result[i] = map[string]json.RawMessage{}
+		}
+	}
+	return result
+}
+
+func changedFields(before, after map[string]json.RawMessage) []string {
+	var fields []string
+	for field, value := range after {
+		if previous, ok := before[field]; !ok || string(previous) != string(value) {
+			fields = append(fields, field)
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
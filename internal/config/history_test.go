@@ -0,0 +1,96 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffPathConfigs(t *testing.T) {
+	users := PathConfig{Name: "users", Pattern: "^/users$", Response: ResponseConfig{StatusCode: 200}}
+	usersFailing := PathConfig{Name: "users", Pattern: "^/users$", Response: ResponseConfig{StatusCode: 500}, ErrorEvery: 2}
+	health := PathConfig{Pattern: "^/health$"}
+
+	tests := []struct {
+		name        string
+		before      []PathConfig
+		after       []PathConfig
+		wantChanged bool
+		want        []ConfigChange
+	}{
+		{name: "unchanged", before: []PathConfig{users}, after: []PathConfig{users}},
+		{
+			name:        "added and deleted",
+			before:      []PathConfig{users},
+			after:       []PathConfig{health},
+			wantChanged: true,
+			want: []ConfigChange{
+				{Op: ChangeAdded, Name: "^/health$", After: &health},
+				{Op: ChangeDeleted, Name: "users", Before: &users},
+			},
+		},
+		{
+			name:        "updated",
+			before:      []PathConfig{users, health},
+			after:       []PathConfig{usersFailing, health},
+			wantChanged: true,
+			want: []ConfigChange{
+				{Op: ChangeUpdated, Name: "users", Fields: []string{"errorEvery", "response"}, Before: &users, After: &usersFailing},
+			},
+		},
+		{
+			name:        "reordered",
+			before:      []PathConfig{users, health},
+			after:       []PathConfig{health, users},
+			wantChanged: true,
+			want:        []ConfigChange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, changed := DiffPathConfigs(tt.before, tt.after)
+			if changed != tt.wantChanged {
+				t.Fatalf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if changed && !reflect.DeepEqual(changes, tt.want) {
+				t.Errorf("changes = %+v\nwant %+v", changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(2)
+	a := []PathConfig{{Name: "a", Pattern: "^/a$"}}
+	ab := append(a, PathConfig{Name: "b", Pattern: "^/b$"})
+
+	if _, ok := h.Record("ada", "POST /config/paths", nil, a); !ok {
+		t.Fatal("first change not recorded")
+	}
+	if _, ok := h.Record("ada", "POST /config/paths", a, a); ok {
+		t.Error("recorded a version without changes")
+	}
+	h.Record("bob", "POST /config/paths", a, ab)
+	v, _ := h.Record("bob", "DELETE /config/paths/b", ab, a)
+
+	var versions []int
+	for _, v := range h.Versions() {
+		versions = append(versions, v.Version)
+	}
+	if !reflect.DeepEqual(versions, []int{2, 3}) {
+		t.Errorf("kept versions = %v, want [2 3]", versions)
+	}
+	if _, ok := h.Version(1); ok {
+		t.Error("version 1 should have been dropped")
+	}
+	if v.Version != 3 || v.Author != "bob" || v.Configs != 1 || len(v.Changes) != 1 || v.Changes[0].Op != ChangeDeleted {
+		t.Errorf("version = %+v", v)
+	}
+	if two, ok := h.Version(2); !ok || len(two.PathConfigs()) != 2 {
+		t.Errorf("version 2 = %+v", two)
+	}
+
+	var nilHistory *History
+	if _, ok := nilHistory.Record("", "", nil, a); ok || len(nilHistory.Versions()) != 0 {
+		t.Error("nil history recorded a change")
+	}
+}
@@ -20,6 +20,7 @@ func NewLoader() *Loader {
 	return &Loader{
 		config: &ServerConfig{
 			PathMatcher: NewPathMatcher(),
+			History:     NewHistory(0),
 		},
 	}
 }
@@ -41,8 +42,14 @@ func (l *Loader) LoadServerConfig(filepath string) error {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return fmt.Errorf("parsing server config: %w", err)
 	}
+	if cfg.Admin != nil {
+		if err := cfg.Admin.Validate(); err != nil {
+			return fmt.Errorf("server config: %w", err)
+		}
+	}
 
 	cfg.PathMatcher = NewPathMatcher()
+	cfg.History = NewHistory(cfg.HistoryLimit)
 	l.config = &cfg
 	return nil
 }
@@ -51,6 +58,43 @@ func (l *Loader) LoadPathConfigs(dirPath string) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	before := l.config.PathMatcher.GetAllConfigs()
+	if err := loadPathConfigsInto(dirPath, l.config.PathMatcher); err != nil {
+		return err
+	}
+	l.config.History.Record("", "load "+dirPath, before, l.config.PathMatcher.GetAllConfigs())
+	return nil
+}
+
+// LoadListenerConfigs builds a separate PathMatcher for every listener that
+// declares its own paths, either inline or from a directory
+func (l *Loader) LoadListenerConfigs() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.config.Listeners {
+		listener := &l.config.Listeners[i]
+		if listener.PathsDir == "" && len(listener.Paths) == 0 {
+			continue
+		}
+
+		pm := NewPathMatcher()
+		for j := range listener.Paths {
+			if err := pm.Add(&listener.Paths[j]); err != nil {
+				return fmt.Errorf("listener %s: path %d: %w", listener.Name, j, err)
+			}
+		}
+		if listener.PathsDir != "" {
+			if err := loadPathConfigsInto(listener.PathsDir, pm); err != nil {
+				return fmt.Errorf("listener %s: %w", listener.Name, err)
+			}
+		}
+		listener.PathMatcher = pm
+	}
+	return nil
+}
+
+func loadPathConfigsInto(dirPath string, pm PathMatcher) error {
 	return filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -76,7 +120,7 @@ func (l *Loader) LoadPathConfigs(dirPath string) error {
 			return nil // Continue with other files
 		}
 
-		if err := l.config.PathMatcher.Add(&cfg); err != nil {
+		if err := pm.Add(&cfg); err != nil {
 			logger.Error("Failed to add path config %s: %v", path, err)
 			return nil // Continue with other files
 		}
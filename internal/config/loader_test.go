@@ -82,5 +82,37 @@ func TestLoader(t *testing.T) {
 		if match == nil {
 			t.Error("Expected to find matching path config")
 		}
+
+		versions := cfg.History.Versions()
+		if len(versions) != 1 || versions[0].Source != "load "+pathsDir || len(versions[0].Changes) != 1 {
+			t.Errorf("Expected the loaded configs in the history, got %+v", versions)
+		}
 	})
 }
+
+func TestLoaderRejectsIncompleteAdminCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		admin   string
+		wantErr bool
+	}{
+		{"username and password", `{"username": "admin", "password": "secret"}`, false},
+		{"token only", `{"token": "t"}`, false},
+		{"password only", `{"password": "secret"}`, true},
+		{"username only", `{"username": "admin"}`, true},
+		{"token and username", `{"token": "t", "username": "admin"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "server.json")
+			if err := os.WriteFile(file, []byte(`{"admin": `+tt.admin+`}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := NewLoader().LoadServerConfig(file)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadServerConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
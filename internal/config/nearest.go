@@ -0,0 +1,87 @@
+package config
+
+import "sort"
+
+// NearMiss is a path config that almost matched a request
+type NearMiss struct {
+	Name    string   `json:"name,omitempty"`
+	Pattern string   `json:"pattern"`
+	Methods []string `json:"methods,omitempty"`
+	// Reason is "method not allowed" when only the method differs and
+	// "path differs" otherwise
+	Reason string `json:"reason"`
+	// Similarity between the path and the pattern's sample paths, 0 to 1
+	Similarity float64 `json:"similarity"`
+}
+
+const (
+	ReasonMethodNotAllowed = "method not allowed"
+	ReasonPathDiffers      = "path differs"
+)
+
+// nearMissSamples is the number of sample paths compared per pattern
+const nearMissSamples = 8
+
+// NearestConfigs returns up to limit configs closest to a request none of
+// them matched. Configs whose pattern matches but whose methods do not come
+// first, the rest are ranked by similarity of the path to sample paths
+// generated from their pattern.
+func NearestConfigs(configs []PathConfig, path, method string, limit int) []NearMiss {
+	misses := make([]NearMiss, 0, len(configs))
+	for i := range configs {
+		pc := &configs[i]
+		miss := NearMiss{Name: pc.Name, Pattern: pc.Pattern, Methods: pc.Methods, Reason: ReasonPathDiffers}
+		if pc.MatchesPattern(path) {
+			miss.Reason = ReasonMethodNotAllowed
+			miss.Similarity = 1
+		} else {
+			samples := pc.samples
+			if pc.regex == nil {
+				samples = SamplePaths(pc.Pattern, nearMissSamples)
+			}
+			for _, sample := range samples {
+				miss.Similarity = max(miss.Similarity, similarity(path, sample))
+			}
+		}
+		if miss.Similarity > 0 {
+			misses = append(misses, miss)
+		}
+	}
+
+	sort.SliceStable(misses, func(i, j int) bool {
+		return misses[i].Similarity > misses[j].Similarity
+	})
+	if len(misses) > limit {
+		misses = misses[:limit]
+	}
+	return misses
+}
+
+// similarity is one minus the edit distance relative to the longer string
+func similarity(a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,41 @@
+package config
+
+import "testing"
+
+func TestNearestConfigs(t *testing.T) {
+	pm := NewPathMatcher()
+	for _, pc := range []PathConfig{
+		{Name: "users", Pattern: "^/api/users$", Methods: []string{"GET"}},
+		{Name: "orders", Pattern: "^/api/orders/[0-9]+$"},
+		{Name: "health", Pattern: "^/health$"},
+	} {
+		if err := pm.Add(&pc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configs := pm.GetAllConfigs()
+
+	tests := []struct {
+		name       string
+		path       string
+		method     string
+		wantFirst  string
+		wantReason string
+	}{
+		{"method mismatch ranks first", "/api/users", "DELETE", "users", ReasonMethodNotAllowed},
+		{"typo in path", "/api/user", "GET", "users", ReasonPathDiffers},
+		{"similar to generated sample", "/api/order/1", "GET", "orders", ReasonPathDiffers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			misses := NearestConfigs(configs, tt.path, tt.method, 2)
+			if len(misses) == 0 || len(misses) > 2 {
+				t.Fatalf("got %d near misses, want 1 or 2", len(misses))
+			}
+			if misses[0].Name != tt.wantFirst || misses[0].Reason != tt.wantReason {
+				t.Errorf("first = %s (%s), want %s (%s)", misses[0].Name, misses[0].Reason, tt.wantFirst, tt.wantReason)
+			}
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
 	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"echo-server/pkg/logger"
 )
@@ -19,15 +25,356 @@ type ProxyConfig struct {
 
 // PathConfig represents configuration for a specific path pattern
 type PathConfig struct {
-	Name           string          `json:"name"`
-	Pattern        string          `json:"pattern"`
-	Methods        []string        `json:"methods"`
-	Response       ResponseConfig  `json:"response"`
-	ErrorResponse  *ResponseConfig `json:"errorResponse,omitempty"`
-	ErrorEvery     int             `json:"errorEvery"`
-	CounterEnabled bool            `json:"counterEnabled"`
-	regex          *regexp.Regexp
-	Proxy          *ProxyConfig `json:"proxy,omitempty"` // Add this field
+	Name     string         `json:"name"`
+	Pattern  string         `json:"pattern"`
+	Methods  []string       `json:"methods"`
+	Response ResponseConfig `json:"response"`
+	// Responses replace Response: matched requests get them one after the
+	// other in the order ResponseOrder selects
+	Responses     []WeightedResponse `json:"responses,omitempty"`
+	ResponseOrder string             `json:"responseOrder,omitempty"`
+	ErrorResponse *ResponseConfig    `json:"errorResponse,omitempty"`
+	ErrorEvery    int                `json:"errorEvery"`
+	// ErrorEveryCounter selects the counter ErrorEvery is applied to: "path"
+	// (default, the exact request path), "config", "method" or "global"
+	ErrorEveryCounter string `json:"errorEveryCounter,omitempty"`
+	CounterEnabled    bool   `json:"counterEnabled"`
+	regex             *regexp.Regexp
+	Proxy             *ProxyConfig     `json:"proxy,omitempty"`
+	WebSocket         *WebSocketConfig `json:"websocket,omitempty"`
+	Logging           *LoggingConfig   `json:"logging,omitempty"`
+	// RequestValidation checks matched requests against an OpenAPI document,
+	// overriding the server wide setting
+	RequestValidation *RequestValidationConfig `json:"requestValidation,omitempty"`
+	// TTL removes the config once it has been in place that long, ExpiresAt
+	// at a fixed time. TTL sets ExpiresAt when the config is added.
+	TTL       Duration  `json:"ttl,omitzero"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	// MaxUses removes the config after it answered that many requests
+	MaxUses int `json:"maxUses,omitempty"`
+	// uses counts the requests matched, shared by the copies of a config kept
+	// across updates
+	uses *atomic.Int64
+	// matchCount is the value of uses for the request Match returned the
+	// config for
+	matchCount int64
+	// samples are paths the pattern matches, for ranking near misses
+	samples []string
+}
+
+// WeightedResponse is one of the Responses of a config. Weight is only used
+// with ResponseOrderRandom, zero counts as one.
+type WeightedResponse struct {
+	ResponseConfig
+	Weight int `json:"weight,omitempty"`
+}
+
+// Orders Responses are returned in
+const (
+	// ResponseOrderSequence returns them in order, then keeps returning the
+	// last one (default)
+	ResponseOrderSequence = "sequence"
+	// ResponseOrderLoop starts over after the last one
+	ResponseOrderLoop = "loop"
+	// ResponseOrderRandom picks one at random, by weight
+	ResponseOrderRandom = "random"
+)
+
+// ResponseFor returns the response for the n-th request answered from
+// Responses, counting from one, and its index in Responses. Without Responses
+// it returns Response and -1. The position is kept in the counters, so it is
+// reset and snapshotted with them.
+func (p *PathConfig) ResponseFor(n int64) (ResponseConfig, int) {
+	if len(p.Responses) == 0 {
+		return p.Response, -1
+	}
+
+	i := 0
+	switch p.ResponseOrder {
+	case ResponseOrderLoop:
+		i = int((max(n, 1) - 1) % int64(len(p.Responses)))
+	case ResponseOrderRandom:
+		total := 0
+		for _, resp := range p.Responses {
+			total += max(resp.Weight, 1)
+		}
+		pick := rand.IntN(total)
+		for i = range p.Responses {
+			if pick -= max(p.Responses[i].Weight, 1); pick < 0 {
+				break
+			}
+		}
+	default:
+		i = int(min(max(n, 1), int64(len(p.Responses)))) - 1
+	}
+	return p.Responses[i].ResponseConfig, i
+}
+
+// MatchCount returns the number of requests the config answered. On a config
+// Claim was called on it includes that request.
+func (p *PathConfig) MatchCount() int64 {
+	if p.matchCount > 0 {
+		return p.matchCount
+	}
+	if p.uses == nil {
+		return 0
+	}
+	return p.uses.Load()
+}
+
+// Temporary reports whether the config removes itself. Temporary configs are
+// matched before the others, so they can override a path for a while.
+func (p *PathConfig) Temporary() bool {
+	return p.TTL.Duration > 0 || !p.ExpiresAt.IsZero() || p.MaxUses > 0
+}
+
+// RemainingUses returns how many more requests the config answers, false
+// when it is not limited
+func (p *PathConfig) RemainingUses() (int, bool) {
+	if p.MaxUses <= 0 {
+		return 0, false
+	}
+	used := 0
+	if p.uses != nil {
+		used = int(p.uses.Load())
+	}
+	return max(p.MaxUses-used, 0), true
+}
+
+// spent reports whether the config has expired or used up its uses at now
+func (p *PathConfig) spent(now time.Time) bool {
+	if !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt) {
+		return true
+	}
+	remaining, limited := p.RemainingUses()
+	return limited && remaining == 0
+}
+
+// Claim takes one use of a config returned by Match for the request it is
+// answering. It returns false when the config expired or a concurrent
+// request took its last use since it matched.
+func (p *PathConfig) Claim() bool {
+	if p.uses == nil || p.spent(time.Now()) {
+		return false
+	}
+	if p.MaxUses <= 0 {
+		p.matchCount = p.uses.Add(1)
+		return true
+	}
+	for {
+		used := p.uses.Load()
+		if used >= int64(p.MaxUses) {
+			return false
+		}
+		if p.uses.CompareAndSwap(used, used+1) {
+			p.matchCount = used + 1
+			return true
+		}
+	}
+}
+
+// MatchOrder returns configs in the order requests are matched against them:
+// temporary configs first, each group in its original order
+func MatchOrder(configs []PathConfig) []PathConfig {
+	ordered := make([]PathConfig, 0, len(configs))
+	for _, temporary := range []bool{true, false} {
+		for _, cfg := range configs {
+			if cfg.Temporary() == temporary {
+				ordered = append(ordered, cfg)
+			}
+		}
+	}
+	return ordered
+}
+
+// Counters ErrorEvery can be keyed on
+const (
+	CounterPath   = "path"
+	CounterConfig = "config"
+	CounterMethod = "method"
+	CounterGlobal = "global"
+)
+
+// CounterKey identifies the config in per config counters: its name, or its
+// pattern for unnamed configs
+func (p *PathConfig) CounterKey() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Pattern
+}
+
+// nameSeparators are runs of characters left out of generated names
+var nameSeparators = regexp.MustCompile(`[^a-z0-9._]+`)
+
+// GeneratedName names a config after a method and path, such as get-users-7
+// for GET /users/7. The name has no slashes, so it can be used in
+// /config/{name}.
+func GeneratedName(method, path string) string {
+	return strings.Trim(nameSeparators.ReplaceAllString(strings.ToLower(method+" "+path), "-"), "-")
+}
+
+// ReservedNames are the /config/{name} segments taken by admin actions such
+// as /config/export. Configs with these names could not be fetched, replaced
+// or deleted by name.
+var ReservedNames = []string{"batch", "examples", "explain", "export", "history", "import", "rollback", "validate"}
+
+// LoggingConfig controls logging for requests matched by a path config
+type LoggingConfig struct {
+	// Level overrides the global log level for matched requests
+	Level           string   `json:"level,omitempty"`
+	LogRequestBody  bool     `json:"logRequestBody,omitempty"`
+	LogResponseBody bool     `json:"logResponseBody,omitempty"`
+	RedactHeaders   []string `json:"redactHeaders,omitempty"`
+	// MaxBodyBytes limits logged bodies, DefaultMaxLoggedBodyBytes when zero
+	MaxBodyBytes int `json:"maxBodyBytes,omitempty"`
+}
+
+// DefaultMaxLoggedBodyBytes is the body size logged when MaxBodyBytes is unset
+const DefaultMaxLoggedBodyBytes = 4096
+
+// BodyLimit returns the maximum number of body bytes to log
+func (lc *LoggingConfig) BodyLimit() int {
+	if lc.MaxBodyBytes > 0 {
+		return lc.MaxBodyBytes
+	}
+	return DefaultMaxLoggedBodyBytes
+}
+
+// RequestValidationConfig checks requests against an OpenAPI 3 document.
+// Requests violating it get StatusCode (400 when zero) and Headers with a
+// report of every problem instead of the configured response.
+type RequestValidationConfig struct {
+	// Spec is the path of the document in JSON or YAML
+	Spec string `json:"spec"`
+	// BasePath is prepended to the document's paths, the path of its first
+	// server URL when empty
+	BasePath   string            `json:"basePath,omitempty"`
+	StatusCode int               `json:"statusCode,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	// AllowUndocumented lets requests through that no operation describes
+	AllowUndocumented bool `json:"allowUndocumented,omitempty"`
+}
+
+// DefaultValidationStatus is returned for invalid requests when no status
+// code is configured
+const DefaultValidationStatus = 400
+
+// ResponseStatus returns the status code for invalid requests
+func (vc *RequestValidationConfig) ResponseStatus() int {
+	if vc.StatusCode != 0 {
+		return vc.StatusCode
+	}
+	return DefaultValidationStatus
+}
+
+// WebSocketConfig defines how WebSocket upgrade requests on a path are handled
+type WebSocketConfig struct {
+	// Mode is either "echo" (default), which sends every message back,
+	// or "script", which replies according to Rules
+	Mode     string           `json:"mode,omitempty"`
+	Rules    []WebSocketRule  `json:"rules,omitempty"`
+	Periodic []WebSocketTimer `json:"periodic,omitempty"`
+}
+
+// WebSocketRule replies to incoming messages matching a regex. When JSONField
+// is set the regex is applied to that (dot separated) field of a JSON message
+// instead of the raw message text.
+type WebSocketRule struct {
+	Match     string   `json:"match"`
+	JSONField string   `json:"jsonField,omitempty"`
+	Reply     string   `json:"reply"`
+	Delay     Duration `json:"delay,omitempty"`
+	regex     *regexp.Regexp
+}
+
+// WebSocketTimer sends a server initiated message every Interval. Count
+// limits the number of messages sent, zero means unlimited.
+type WebSocketTimer struct {
+	Interval Duration `json:"interval"`
+	Message  string   `json:"message"`
+	Count    int      `json:"count,omitempty"`
+}
+
+const (
+	WebSocketModeEcho   = "echo"
+	WebSocketModeScript = "script"
+)
+
+// MatchRule returns the first rule matching the given message
+func (ws *WebSocketConfig) MatchRule(message []byte) (*WebSocketRule, bool) {
+	var parsed interface{}
+	jsonParsed := false
+
+	for i := range ws.Rules {
+		rule := &ws.Rules[i]
+		subject := string(message)
+		if rule.JSONField != "" {
+			if !jsonParsed {
+				jsonParsed = true
+				if err := json.Unmarshal(message, &parsed); err != nil {
+					parsed = nil
+				}
+			}
+			value, ok := lookupJSONField(parsed, rule.JSONField)
+			if !ok {
+				continue
+			}
+			subject = value
+		}
+		if rule.regex.MatchString(subject) {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+func (ws *WebSocketConfig) compile() error {
+	switch ws.Mode {
+	case "", WebSocketModeEcho, WebSocketModeScript:
+	default:
+		return fmt.Errorf("invalid websocket mode: %s", ws.Mode)
+	}
+	for i := range ws.Rules {
+		regex, err := regexp.Compile(ws.Rules[i].Match)
+		if err != nil {
+			return fmt.Errorf("websocket rule %d: %w", i, err)
+		}
+		ws.Rules[i].regex = regex
+	}
+	for i, timer := range ws.Periodic {
+		if timer.Interval.Duration <= 0 {
+			return fmt.Errorf("websocket periodic message %d: interval must be positive", i)
+		}
+	}
+	return nil
+}
+
+// lookupJSONField walks a dot separated path through decoded JSON and
+// returns the value found there formatted as a string
+func lookupJSONField(data interface{}, field string) (string, bool) {
+	current := data
+	for _, key := range strings.Split(field, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if current, ok = obj[key]; !ok {
+			return "", false
+		}
+	}
+	switch value := current.(type) {
+	case string:
+		return value, true
+	case nil:
+		return "null", true
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(encoded), true
+	}
 }
 
 // ResponseConfig defines the response behavior
@@ -56,6 +403,11 @@ type PathMatcher interface {
 	Clear()
 	GetAllConfigs() []PathConfig // New method
 	DeleteByName(name string) bool
+	// Replace swaps all configurations for configs at once. Nothing changes
+	// when one of them is invalid.
+	Replace(configs []PathConfig) error
+	// Update replaces the configurations with the result of fn at once
+	Update(fn func(configs []PathConfig) ([]PathConfig, error)) error
 }
 
 // pathMatcherImpl implements the PathMatcher interface
@@ -73,35 +425,170 @@ func NewPathMatcher() PathMatcher {
 
 // Add adds a new path configuration
 func (pm *pathMatcherImpl) Add(cfg *PathConfig) error {
-	regex, err := regexp.Compile(cfg.Pattern)
-	if err != nil {
+	if err := compile(cfg); err != nil {
 		return err
 	}
 
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
-	cfg.regex = regex
 	pm.configs = append(pm.configs, *cfg)
 	logger.Info("Added path pattern: %s", cfg.Pattern)
 	return nil
 }
 
-// Match finds the first matching configuration for a path
+// Replace compiles copies of configs and only swaps them in when all of
+// them compile, so requests never see a partial set
+func (pm *pathMatcherImpl) Replace(configs []PathConfig) error {
+	fresh := make([]PathConfig, len(configs))
+	for i, cfg := range configs {
+		// Replaced configs start counting their uses again
+		cfg.uses, cfg.matchCount = nil, 0
+		fresh[i] = cfg
+	}
+	compiled, err := compileAll(fresh)
+	if err != nil {
+		return err
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.configs = compiled
+	logger.Info("Replaced all path patterns with %d configs", len(compiled))
+	return nil
+}
+
+// Update calls fn with a copy of the current configs, without expired and used
+// up ones, and swaps in the configs it returns, holding the lock throughout so
+// concurrent updates are applied one after the other. Nothing changes when fn
+// fails or a returned config does not compile.
+func (pm *pathMatcherImpl) Update(fn func(configs []PathConfig) ([]PathConfig, error)) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	current := pm.live(time.Now())
+	if spent := len(pm.configs) - len(current); spent > 0 {
+		logger.Info("Removing %d expired or used up path configs", spent)
+	}
+	next, err := fn(current)
+	if err != nil {
+		return err
+	}
+	compiled, err := compileAll(next)
+	if err != nil {
+		return err
+	}
+
+	pm.configs = compiled
+	logger.Info("Updated path patterns, %d configs loaded", len(compiled))
+	return nil
+}
+
+// compileAll compiles copies of configs
+func compileAll(configs []PathConfig) ([]PathConfig, error) {
+	compiled := make([]PathConfig, len(configs))
+	copy(compiled, configs)
+	for i := range compiled {
+		if ws := compiled[i].WebSocket; ws != nil {
+			// Rules are compiled in place, configs kept from the current set
+			// are still in use by running connections
+			clone := *ws
+			clone.Rules = append([]WebSocketRule(nil), ws.Rules...)
+			compiled[i].WebSocket = &clone
+		}
+		if err := compile(&compiled[i]); err != nil {
+			return nil, fmt.Errorf("path %d (%s): %w", i, compiled[i].CounterKey(), err)
+		}
+	}
+	return compiled, nil
+}
+
+// CheckPathConfigs reports the first of configs that Replace and Update
+// would refuse
+func CheckPathConfigs(configs []PathConfig) error {
+	_, err := compileAll(configs)
+	return err
+}
+
+// compile checks cfg the way Add does and prepares it for matching
+func compile(cfg *PathConfig) error {
+	regex, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return err
+	}
+	if contains(ReservedNames, cfg.Name) {
+		return fmt.Errorf("name %q is reserved for /config/%s", cfg.Name, cfg.Name)
+	}
+	if cfg.WebSocket != nil {
+		if err := cfg.WebSocket.compile(); err != nil {
+			return err
+		}
+	}
+	switch cfg.ErrorEveryCounter {
+	case "", CounterPath, CounterConfig, CounterMethod, CounterGlobal:
+	default:
+		return fmt.Errorf("invalid errorEveryCounter: %s", cfg.ErrorEveryCounter)
+	}
+	switch cfg.ResponseOrder {
+	case "", ResponseOrderSequence, ResponseOrderLoop, ResponseOrderRandom:
+	default:
+		return fmt.Errorf("invalid responseOrder: %s", cfg.ResponseOrder)
+	}
+	if cfg.Logging != nil && cfg.Logging.Level != "" {
+		if _, err := logger.ParseLevel(cfg.Logging.Level); err != nil {
+			return err
+		}
+	}
+	if cfg.TTL.Duration < 0 || cfg.MaxUses < 0 {
+		return fmt.Errorf("ttl and maxUses must not be negative")
+	}
+	if cfg.TTL.Duration > 0 && cfg.ExpiresAt.IsZero() {
+		cfg.ExpiresAt = time.Now().Add(cfg.TTL.Duration).UTC()
+	}
+	if cfg.uses == nil {
+		cfg.uses = new(atomic.Int64)
+	}
+	cfg.samples = SamplePaths(cfg.Pattern, nearMissSamples)
+	cfg.regex = regex
+	return nil
+}
+
+// Match finds the first matching configuration for a path, trying temporary
+// configs first and skipping expired and used up ones. The config returned
+// is a copy; nothing is used up until Claim is called on it.
 func (pm *pathMatcherImpl) Match(path, method string) (*PathConfig, bool) {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
 
-	for i := range pm.configs {
-		if pm.configs[i].regex.MatchString(path) {
-			if len(pm.configs[i].Methods) == 0 || contains(pm.configs[i].Methods, method) {
-				return &pm.configs[i], true
+	now := time.Now()
+	for _, temporary := range []bool{true, false} {
+		for i := range pm.configs {
+			cfg := &pm.configs[i]
+			if cfg.Temporary() != temporary || !cfg.MatchesPattern(path) || !cfg.MatchesMethod(method) {
+				continue
+			}
+			if !cfg.spent(now) {
+				matched := *cfg
+				return &matched, true
 			}
 		}
 	}
 	return nil, false
 }
 
+// MatchesPattern reports whether path matches the pattern of a config that
+// was added to a PathMatcher
+func (p *PathConfig) MatchesPattern(path string) bool {
+	return p.regex != nil && p.regex.MatchString(path)
+}
+
+// MatchesMethod reports whether the config accepts method, configs without
+// methods accept all of them
+func (p *PathConfig) MatchesMethod(method string) bool {
+	return len(p.Methods) == 0 || contains(p.Methods, method)
+}
+
 func (pm *pathMatcherImpl) DeleteByName(name string) bool {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
@@ -125,13 +612,24 @@ func (pm *pathMatcherImpl) Clear() {
 	logger.Info("Cleared all path patterns")
 }
 
-// GetAllConfigs retrieves all path configurations
+// GetAllConfigs retrieves all path configurations that have not expired or
+// been used up
 func (pm *pathMatcherImpl) GetAllConfigs() []PathConfig {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
 
-	configs := make([]PathConfig, len(pm.configs))
-	copy(configs, pm.configs)
+	return pm.live(time.Now())
+}
+
+// live returns a copy of the configs not spent at now. Spent configs are
+// dropped for good by the next Update.
+func (pm *pathMatcherImpl) live(now time.Time) []PathConfig {
+	configs := make([]PathConfig, 0, len(pm.configs))
+	for _, cfg := range pm.configs {
+		if !cfg.spent(now) {
+			configs = append(configs, cfg)
+		}
+	}
 	return configs
 }
 
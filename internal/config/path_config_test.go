@@ -1,6 +1,10 @@
 package config
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"time"
+)
 
 func TestPathMatcher(t *testing.T) {
 
@@ -61,3 +65,191 @@ func TestPathMatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestPathMatcherReplace(t *testing.T) {
+	pm := NewPathMatcher()
+	if err := pm.Add(&PathConfig{Name: "old", Pattern: "^/old$"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := pm.Replace([]PathConfig{{Name: "new", Pattern: "^/new$"}, {Name: "broken", Pattern: "("}})
+	if err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+	if _, ok := pm.Match("/old", "GET"); !ok {
+		t.Error("failed replace changed the loaded configs")
+	}
+	if _, ok := pm.Match("/new", "GET"); ok {
+		t.Error("failed replace added a config")
+	}
+
+	if err := pm.Replace([]PathConfig{{Name: "new", Pattern: "^/new$"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pm.Match("/old", "GET"); ok {
+		t.Error("old config still matches after replace")
+	}
+	if cfg, ok := pm.Match("/new", "GET"); !ok || cfg.Name != "new" {
+		t.Errorf("Match(/new) = %+v, %v", cfg, ok)
+	}
+}
+
+func TestPathMatcherReservedNames(t *testing.T) {
+	pm := NewPathMatcher()
+	for _, name := range ReservedNames {
+		if err := pm.Add(&PathConfig{Name: name, Pattern: "^/a$"}); err == nil {
+			t.Errorf("Add accepted the reserved name %q", name)
+		}
+	}
+	if err := pm.Add(&PathConfig{Name: "exports", Pattern: "^/a$"}); err != nil {
+		t.Errorf("Add(exports) failed: %v", err)
+	}
+}
+
+func TestPathMatcherUpdate(t *testing.T) {
+	pm := NewPathMatcher()
+	if err := pm.Add(&PathConfig{Name: "a", Pattern: "^/a$"}); err != nil {
+		t.Fatal(err)
+	}
+
+	errFailed := errors.New("failed")
+	if err := pm.Update(func(configs []PathConfig) ([]PathConfig, error) {
+		return nil, errFailed
+	}); err != errFailed {
+		t.Fatalf("Update() = %v, want %v", err, errFailed)
+	}
+	if err := pm.Update(func(configs []PathConfig) ([]PathConfig, error) {
+		return append(configs, PathConfig{Name: "b", Pattern: "("}), nil
+	}); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+	if got := len(pm.GetAllConfigs()); got != 1 {
+		t.Fatalf("failed updates changed the configs, %d loaded", got)
+	}
+
+	if err := pm.Update(func(configs []PathConfig) ([]PathConfig, error) {
+		return append(configs, PathConfig{Name: "b", Pattern: "^/b$"}), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/a", "/b"} {
+		if _, ok := pm.Match(path, "GET"); !ok {
+			t.Errorf("%s not matched after update", path)
+		}
+	}
+}
+
+func TestPathMatcherTemporary(t *testing.T) {
+	pm := NewPathMatcher()
+	configs := []*PathConfig{
+		{Name: "payments", Pattern: "^/payments$"},
+		{Name: "fail-once", Pattern: "^/payments$", MaxUses: 1},
+		{Name: "expired", Pattern: "^/orders$", ExpiresAt: time.Now().Add(-time.Minute)},
+		{Name: "ttl", Pattern: "^/orders$", TTL: Duration{Duration: time.Hour}},
+	}
+	for _, cfg := range configs {
+		if err := pm.Add(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/payments", want: "fail-once"},
+		{path: "/payments", want: "payments"},
+		{path: "/orders", want: "ttl"},
+	}
+	// Matching alone uses nothing up
+	if cfg, ok := pm.Match("/payments", "GET"); !ok || cfg.Name != "fail-once" {
+		t.Fatalf("Match(/payments) = %+v, want fail-once", cfg)
+	}
+	for _, tt := range tests {
+		cfg, ok := pm.Match(tt.path, "GET")
+		if !ok || cfg.Name != tt.want {
+			t.Errorf("Match(%s) = %+v, want %s", tt.path, cfg, tt.want)
+			continue
+		}
+		if !cfg.Claim() {
+			t.Errorf("Claim() of %s failed", cfg.Name)
+		}
+	}
+
+	live := pm.GetAllConfigs()
+	if len(live) != 2 || live[0].Name != "payments" || live[1].Name != "ttl" {
+		t.Fatalf("GetAllConfigs() = %+v, want payments and ttl", live)
+	}
+	if expires := live[1].ExpiresAt; time.Until(expires) < 59*time.Minute {
+		t.Errorf("ttl config expires at %v", expires)
+	}
+
+	// Updates keep the remaining uses of the configs they keep
+	if err := pm.Add(&PathConfig{Name: "twice", Pattern: "^/twice$", MaxUses: 2}); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := pm.Match("/twice", "GET")
+	second, _ := pm.Match("/twice", "GET")
+	if !first.Claim() || first.MatchCount() != 1 {
+		t.Fatalf("first Claim() failed, match count %d", first.MatchCount())
+	}
+	if err := pm.Update(func(configs []PathConfig) ([]PathConfig, error) { return configs, nil }); err != nil {
+		t.Fatal(err)
+	}
+	twice := pm.GetAllConfigs()[2]
+	if remaining, ok := twice.RemainingUses(); !ok || remaining != 1 {
+		t.Errorf("RemainingUses() = %d, %v, want 1", remaining, ok)
+	}
+	if !second.Claim() || second.MatchCount() != 2 {
+		t.Errorf("second Claim() failed, match count %d", second.MatchCount())
+	}
+	if first.Claim() {
+		t.Error("Claim() succeeded after the last use")
+	}
+	if _, ok := pm.Match("/twice", "GET"); ok {
+		t.Error("config matched after its last use")
+	}
+}
+
+func TestResponseFor(t *testing.T) {
+	cfg := PathConfig{
+		Response: ResponseConfig{StatusCode: 200},
+		Responses: []WeightedResponse{
+			{ResponseConfig: ResponseConfig{StatusCode: 503}},
+			{ResponseConfig: ResponseConfig{StatusCode: 200}, Weight: 3},
+		},
+	}
+
+	tests := []struct {
+		order string
+		n     int64
+		want  int
+	}{
+		{order: "", n: 1, want: 0},
+		{order: ResponseOrderSequence, n: 2, want: 1},
+		{order: ResponseOrderSequence, n: 5, want: 1},
+		{order: ResponseOrderLoop, n: 3, want: 0},
+		{order: ResponseOrderLoop, n: 4, want: 1},
+	}
+	for _, tt := range tests {
+		cfg.ResponseOrder = tt.order
+		if resp, i := cfg.ResponseFor(tt.n); i != tt.want || resp.StatusCode != cfg.Responses[tt.want].StatusCode {
+			t.Errorf("ResponseFor(%d) with order %q = %d, want %d", tt.n, tt.order, i, tt.want)
+		}
+	}
+
+	// Weights make the second response three times as likely
+	cfg.ResponseOrder = ResponseOrderRandom
+	picked := make([]int, 2)
+	for n := int64(1); n <= 4000; n++ {
+		_, i := cfg.ResponseFor(n)
+		picked[i]++
+	}
+	if picked[0] < 700 || picked[0] > 1300 {
+		t.Errorf("random picks = %v, want about 1000 and 3000", picked)
+	}
+
+	if resp, i := (&PathConfig{Response: ResponseConfig{StatusCode: 201}}).ResponseFor(1); i != -1 || resp.StatusCode != 201 {
+		t.Errorf("ResponseFor() without responses = %+v, %d", resp, i)
+	}
+}
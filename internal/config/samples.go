@@ -0,0 +1,149 @@
+package config
+
+import (
+	"regexp/syntax"
+	"strings"
+)
+
+// maxSampleAlternatives bounds the samples produced for one part of a pattern
+const maxSampleAlternatives = 8
+
+// SamplePaths returns up to limit example strings matched by pattern. They
+// cover optional parts both present and absent and each branch of an
+// alternation, which is enough to compare patterns or to fill in examples.
+// Patterns that do not compile return no samples.
+func SamplePaths(pattern string, limit int) []string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	samples := samplesFor(re.Simplify())
+	if len(samples) > limit {
+		samples = samples[:limit]
+	}
+	return samples
+}
+
+func samplesFor(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return []string{strings.ToLower(string(re.Rune))}
+		}
+		return []string{string(re.Rune)}
+	case syntax.OpCharClass:
+		return charClassSamples(re.Rune)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return []string{"x"}
+	case syntax.OpCapture:
+		return samplesFor(re.Sub[0])
+	case syntax.OpStar, syntax.OpQuest:
+		return limitSamples(append([]string{""}, samplesFor(re.Sub[0])...))
+	case syntax.OpPlus:
+		return samplesFor(re.Sub[0])
+	case syntax.OpRepeat:
+		sub := samplesFor(re.Sub[0])
+		result := []string{""}
+		for i := 0; i < re.Min; i++ {
+			result = concatSamples(result, sub)
+		}
+		if re.Min == 0 {
+			result = limitSamples(append(result, sub...))
+		}
+		return result
+	case syntax.OpConcat:
+		result := []string{""}
+		for _, sub := range re.Sub {
+			result = concatSamples(result, samplesFor(sub))
+		}
+		return result
+	case syntax.OpAlternate:
+		var result []string
+		for _, sub := range re.Sub {
+			result = append(result, samplesFor(sub)...)
+		}
+		return limitSamples(result)
+	default:
+		// Anchors, word boundaries and empty matches contribute nothing
+		return []string{""}
+	}
+}
+
+// charClassSamples returns a readable rune and the first rune of every range
+// in the class, so classes built from alternations keep each branch
+func charClassSamples(ranges []rune) []string {
+	samples := []string{string(sampleRune(ranges))}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if r := ranges[i]; r > ' ' && r != 0x7f {
+			samples = append(samples, string(r))
+		}
+	}
+	return limitSamples(samples)
+}
+
+// sampleRune picks a readable rune from a character class given as ranges
+func sampleRune(ranges []rune) rune {
+	for _, preferred := range "a0-" {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= preferred && preferred <= ranges[i+1] {
+				return preferred
+			}
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		// Skip control characters and the path separator where possible
+		for r := ranges[i]; r <= ranges[i+1] && r < ranges[i]+128; r++ {
+			if r > ' ' && r != '/' && r != 0x7f {
+				return r
+			}
+		}
+	}
+	if len(ranges) > 0 {
+		return ranges[0]
+	}
+	return 'x'
+}
+
+func concatSamples(prefixes, suffixes []string) []string {
+	var result []string
+	for _, p := range prefixes {
+		for _, s := range suffixes {
+			result = append(result, p+s)
+		}
+	}
+	return limitSamples(result)
+}
+
+func limitSamples(samples []string) []string {
+	seen := make(map[string]bool, len(samples))
+	result := samples[:0:0]
+	for _, s := range samples {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	if len(result) > maxSampleAlternatives {
+		result = result[:maxSampleAlternatives]
+	}
+	return result
+}
+
+// SamplePath returns a concrete path the config's pattern matches, preferring
+// samples that look like absolute paths. It returns false when none of the
+// generated samples match, which happens with word boundaries or anchors in
+// the middle of a pattern. The config must have been added to a PathMatcher.
+func (p *PathConfig) SamplePath() (string, bool) {
+	samples := SamplePaths(p.Pattern, maxSampleAlternatives)
+	for _, sample := range samples {
+		if strings.HasPrefix(sample, "/") && p.MatchesPattern(sample) {
+			return sample, true
+		}
+	}
+	for _, sample := range samples {
+		if path := "/" + strings.TrimPrefix(sample, "/"); p.MatchesPattern(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
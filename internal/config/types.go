@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -45,8 +46,150 @@ type ServerConfig struct {
 	ReadTimeout     Duration       `json:"readTimeout"`
 	WriteTimeout    Duration       `json:"writeTimeout"`
 	DefaultResponse ResponseConfig `json:"defaultResponse"`
-	PathMatcher     PathMatcher    `json:"pathMatcher"`
+	PathMatcher     PathMatcher    `json:"-"`
 	Paths           []PathConfig   `json:"paths"`
+	TLS             *TLSConfig     `json:"tls,omitempty"`
+	// DisableHTTP2 restricts the listener to HTTP/1.1. Otherwise HTTP/2 is
+	// offered over TLS (ALPN) and as cleartext h2c with prior knowledge.
+	DisableHTTP2 bool `json:"disableHTTP2,omitempty"`
+	// Listeners are served in addition to the main Host/Port listener
+	Listeners []ListenerConfig `json:"listeners,omitempty"`
+	// UnixSocket additionally serves the main configuration on a Unix socket
+	UnixSocket string `json:"unixSocket,omitempty"`
+	SocketMode string `json:"socketMode,omitempty"`
+	// Tracing enables OpenTelemetry tracing when present
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+	// CounterSnapshot persists counters to a file so they survive restarts
+	CounterSnapshot *CounterSnapshotConfig `json:"counterSnapshot,omitempty"`
+	// ExcludeAdminFromCounters stops requests to the admin endpoints from
+	// being counted
+	ExcludeAdminFromCounters bool `json:"excludeAdminFromCounters,omitempty"`
+	// Strict answers requests no path config matches with 404 and the
+	// closest configs instead of DefaultResponse
+	Strict bool `json:"strict,omitempty"`
+	// Admin moves the admin endpoints out of the way of mocked paths and
+	// protects them
+	Admin *AdminConfig `json:"admin,omitempty"`
+	// RequestValidation checks every request against an OpenAPI document
+	RequestValidation *RequestValidationConfig `json:"requestValidation,omitempty"`
+	// HistoryLimit is the number of path config versions kept for rollback,
+	// DefaultHistoryLimit when zero
+	HistoryLimit int `json:"historyLimit,omitempty"`
+	// History records changes to the path configs of PathMatcher
+	History *History `json:"-"`
+}
+
+// AdminConfig controls where /config, /counter, /unmatched, /requests,
+// /namespaces, /admin and /ui are served. With Prefix they move below that path on every
+// listener, with Port they are only served by a dedicated listener. Token
+// enables bearer auth, Username and Password basic auth; either is accepted
+// when both are set.
+type AdminConfig struct {
+	Prefix   string `json:"prefix,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Validate reports basic auth credentials missing their other half
+func (a *AdminConfig) Validate() error {
+	if (a.Username == "") != (a.Password == "") {
+		return errors.New("admin basic auth needs both username and password")
+	}
+	return nil
+}
+
+// CounterSnapshotConfig configures periodic counter snapshots. The file is
+// restored on startup and written every Interval and on shutdown.
+type CounterSnapshotConfig struct {
+	File string `json:"file"`
+	// Interval between snapshots, 30s when zero
+	Interval Duration `json:"interval,omitempty"`
+}
+
+// DefaultSnapshotInterval is used when no snapshot interval is configured
+const DefaultSnapshotInterval = 30 * time.Second
+
+// SnapshotInterval returns the configured interval or the default
+func (c *CounterSnapshotConfig) SnapshotInterval() time.Duration {
+	if c.Interval.Duration <= 0 {
+		return DefaultSnapshotInterval
+	}
+	return c.Interval.Duration
+}
+
+// TracingConfig configures the OpenTelemetry span exporter
+type TracingConfig struct {
+	// Exporter is "otlp" (default, OTLP over HTTP) or "stdout"
+	Exporter string `json:"exporter,omitempty"`
+	// Endpoint is the OTLP collector host:port, localhost:4318 when empty
+	Endpoint    string `json:"endpoint,omitempty"`
+	Insecure    bool   `json:"insecure,omitempty"`
+	ServiceName string `json:"serviceName,omitempty"`
+	// SampleRatio is the fraction of new traces sampled, 1 when zero.
+	// Requests with a sampled parent are always sampled.
+	SampleRatio float64 `json:"sampleRatio,omitempty"`
+}
+
+// ListenerConfig describes an additional listener. A listener with Paths or
+// PathsDir gets its own set of path configs, otherwise it shares the server's.
+// When UnixSocket is set the listener binds that socket path instead of
+// Host/Port; SocketMode is the octal file mode applied to it, e.g. "0660".
+type ListenerConfig struct {
+	Name            string          `json:"name"`
+	Host            string          `json:"host,omitempty"`
+	Port            int             `json:"port"`
+	UnixSocket      string          `json:"unixSocket,omitempty"`
+	SocketMode      string          `json:"socketMode,omitempty"`
+	TLS             *TLSConfig      `json:"tls,omitempty"`
+	DisableHTTP2    bool            `json:"disableHTTP2,omitempty"`
+	DefaultResponse *ResponseConfig `json:"defaultResponse,omitempty"`
+	PathsDir        string          `json:"pathsDir,omitempty"`
+	Paths           []PathConfig    `json:"paths,omitempty"`
+	PathMatcher     PathMatcher     `json:"-"`
+}
+
+// ForListener returns the configuration served by an additional listener:
+// the server settings with the listener's overrides applied
+func (cfg *ServerConfig) ForListener(l ListenerConfig) *ServerConfig {
+	derived := *cfg
+	derived.Listeners = nil
+	derived.UnixSocket = ""
+	derived.Port = l.Port
+	derived.TLS = l.TLS
+	derived.DisableHTTP2 = l.DisableHTTP2
+	if l.Host != "" {
+		derived.Host = l.Host
+	}
+	if l.DefaultResponse != nil {
+		derived.DefaultResponse = *l.DefaultResponse
+	}
+	if l.PathMatcher != nil {
+		// Own path configs get their own history, rolling back the server's
+		// versions would replace them
+		derived.PathMatcher = l.PathMatcher
+		derived.History = NewHistory(cfg.HistoryLimit)
+		derived.History.Record("", "load listener "+l.Name, nil, l.PathMatcher.GetAllConfigs())
+	}
+	return &derived
+}
+
+// TLSConfig enables HTTPS on the listener, either with a certificate and key
+// read from disk or with a certificate generated at startup
+type TLSConfig struct {
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// SelfSigned generates a CA and a server certificate at startup, the CA
+	// certificate is written to CAOutFile so clients can trust it
+	SelfSigned bool     `json:"selfSigned,omitempty"`
+	CAOutFile  string   `json:"caOutFile,omitempty"`
+	Hosts      []string `json:"hosts,omitempty"`
+	// ClientAuth is one of "none" (default), "request", "require", "verify"
+	// (verify if given) or "require-verify" (mutual TLS)
+	ClientAuth   string `json:"clientAuth,omitempty"`
+	ClientCAFile string `json:"clientCAFile,omitempty"`
 }
 
 // Headers represents HTTP headers as key-value pairs
@@ -62,6 +205,7 @@ func NewConfigManager() *ConfigManager {
 	return &ConfigManager{
 		config: &ServerConfig{
 			PathMatcher: NewPathMatcher(),
+			History:     NewHistory(0),
 		},
 	}
 }
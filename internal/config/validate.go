@@ -0,0 +1,566 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"text/template"
+
+	"echo-server/pkg/logger"
+)
+
+// ValidationError is one problem found in a path config. File and Field
+// locate it as precisely as possible, Field is a JSON path such as
+// "response.statusCode" or "websocket.rules[1].match".
+type ValidationError struct {
+	File    string `json:"file,omitempty"`
+	Config  string `json:"config,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File + ": ")
+	}
+	if e.Config != "" {
+		b.WriteString(e.Config + ": ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field + ": ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ValidationReport is the result of validating a set of path configs
+type ValidationReport struct {
+	Valid   bool              `json:"valid"`
+	Configs int               `json:"configs"`
+	Errors  []ValidationError `json:"errors"`
+}
+
+// ValidatedConfig is a decoded path config with the file it came from
+type ValidatedConfig struct {
+	File   string
+	Config PathConfig
+}
+
+// shadowPathLimit is the largest number of paths a pattern may match for
+// shadowing to be checked path by path
+const shadowPathLimit = 64
+
+var validMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// DecodePathConfigStrict decodes a single path config rejecting unknown
+// fields and trailing data
+func DecodePathConfigStrict(data []byte) (PathConfig, error) {
+	var cfg PathConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, describeDecodeError(data, err)
+	}
+	if dec.More() {
+		return cfg, errors.New("unexpected data after the path config")
+	}
+	return cfg, nil
+}
+
+// DecodePathConfigsStrict decodes either a single path config or an array
+// of path configs, rejecting unknown fields
+func DecodePathConfigsStrict(data []byte) ([]PathConfig, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		cfg, err := DecodePathConfigStrict(data)
+		if err != nil {
+			return nil, err
+		}
+		return []PathConfig{cfg}, nil
+	}
+
+	var configs []PathConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&configs); err != nil {
+		return nil, describeDecodeError(data, err)
+	}
+	return configs, nil
+}
+
+// describeDecodeError adds the line and column or the field to JSON errors
+func describeDecodeError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, err)
+	case errors.As(err, &typeErr):
+		line, col := position(data, typeErr.Offset)
+		return fmt.Errorf("line %d, column %d: field %s: cannot use %s as %s", line, col, typeErr.Field, typeErr.Value, typeErr.Type)
+	}
+	return err
+}
+
+func position(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// ValidatePathConfig checks a single path config and returns every problem
+// found. Unlike PathMatcher.Add it also checks status codes, methods,
+// templates, proxy URLs and errorEvery settings.
+func ValidatePathConfig(cfg *PathConfig) []ValidationError {
+	var errs []ValidationError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Config: cfg.CounterKey(), Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if contains(ReservedNames, cfg.Name) {
+		add("name", "name %q is reserved for /config/%s", cfg.Name, cfg.Name)
+	}
+	if cfg.Pattern == "" {
+		add("pattern", "pattern is required")
+	} else if _, err := regexp.Compile(cfg.Pattern); err != nil {
+		add("pattern", "%v", err)
+	}
+	for i, method := range cfg.Methods {
+		if !contains(validMethods, method) {
+			add(fmt.Sprintf("methods[%d]", i), "unknown method %q", method)
+		}
+	}
+
+	validateResponse(add, "response", &cfg.Response)
+	for i := range cfg.Responses {
+		field := fmt.Sprintf("responses[%d]", i)
+		validateResponse(add, field, &cfg.Responses[i].ResponseConfig)
+		if cfg.Responses[i].Weight < 0 {
+			add(field+".weight", "must not be negative")
+		}
+	}
+	switch cfg.ResponseOrder {
+	case "", ResponseOrderSequence, ResponseOrderLoop, ResponseOrderRandom:
+	default:
+		add("responseOrder", "invalid order %q, expected sequence, loop or random", cfg.ResponseOrder)
+	}
+	if cfg.ErrorResponse != nil {
+		validateResponse(add, "errorResponse", cfg.ErrorResponse)
+	}
+	switch {
+	case cfg.ErrorEvery < 0:
+		add("errorEvery", "must not be negative")
+	case cfg.ErrorEvery > 0 && cfg.ErrorResponse == nil:
+		add("errorEvery", "errorEvery is set but errorResponse is missing, no error will be returned")
+	}
+	switch cfg.ErrorEveryCounter {
+	case "", CounterPath, CounterConfig, CounterMethod, CounterGlobal:
+	default:
+		add("errorEveryCounter", "invalid counter %q, expected path, config, method or global", cfg.ErrorEveryCounter)
+	}
+
+	if cfg.Proxy != nil {
+		if u, err := url.Parse(cfg.Proxy.URL); err != nil {
+			add("proxy.url", "%v", err)
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			add("proxy.url", "scheme must be http or https, got %q", u.Scheme)
+		} else if u.Host == "" {
+			add("proxy.url", "host is missing")
+		}
+		if cfg.Proxy.Timeout.Duration < 0 {
+			add("proxy.timeout", "must not be negative")
+		}
+	}
+
+	if ws := cfg.WebSocket; ws != nil {
+		switch ws.Mode {
+		case "", WebSocketModeEcho, WebSocketModeScript:
+		default:
+			add("websocket.mode", "invalid mode %q, expected echo or script", ws.Mode)
+		}
+		for i, rule := range ws.Rules {
+			if _, err := regexp.Compile(rule.Match); err != nil {
+				add(fmt.Sprintf("websocket.rules[%d].match", i), "%v", err)
+			}
+			if err := parseTemplate(rule.Reply); err != nil {
+				add(fmt.Sprintf("websocket.rules[%d].reply", i), "%v", err)
+			}
+		}
+		for i, timer := range ws.Periodic {
+			if timer.Interval.Duration <= 0 {
+				add(fmt.Sprintf("websocket.periodic[%d].interval", i), "must be positive")
+			}
+			if err := parseTemplate(timer.Message); err != nil {
+				add(fmt.Sprintf("websocket.periodic[%d].message", i), "%v", err)
+			}
+		}
+	}
+
+	if vc := cfg.RequestValidation; vc != nil {
+		if vc.Spec == "" {
+			add("requestValidation.spec", "spec is required")
+		}
+		if vc.StatusCode != 0 && (vc.StatusCode < 100 || vc.StatusCode > 599) {
+			add("requestValidation.statusCode", "invalid status code %d", vc.StatusCode)
+		}
+	}
+
+	if cfg.Logging != nil && cfg.Logging.Level != "" {
+		if _, err := logger.ParseLevel(cfg.Logging.Level); err != nil {
+			add("logging.level", "%v", err)
+		}
+	}
+
+	if cfg.TTL.Duration < 0 {
+		add("ttl", "must not be negative")
+	}
+	if cfg.MaxUses < 0 {
+		add("maxUses", "must not be negative")
+	}
+	return errs
+}
+
+func validateResponse(add func(field, format string, args ...interface{}), field string, resp *ResponseConfig) {
+	if resp.StatusCode != 0 && (resp.StatusCode < 100 || resp.StatusCode > 599) {
+		add(field+".statusCode", "invalid status code %d", resp.StatusCode)
+	}
+	if resp.Delay.Duration < 0 {
+		add(field+".delay", "must not be negative")
+	}
+	if err := parseTemplate(resp.Body); err != nil {
+		add(field+".body", "%v", err)
+	}
+}
+
+// parseTemplate checks "template:" prefixed bodies the way they are
+// rendered at request time
+func parseTemplate(body string) error {
+	if !strings.HasPrefix(body, "template:") {
+		return nil
+	}
+	_, err := template.New("validate").Parse(strings.TrimPrefix(body, "template:"))
+	return err
+}
+
+// ValidatePathConfigs validates configs in the order they would be added
+// and reports configs that can never match because an earlier config
+// catches all of their requests
+func ValidatePathConfigs(configs []ValidatedConfig) ValidationReport {
+	report := ValidationReport{Configs: len(configs), Errors: []ValidationError{}}
+
+	for _, vc := range configs {
+		for _, err := range ValidatePathConfig(&vc.Config) {
+			err.File = vc.File
+			report.Errors = append(report.Errors, err)
+		}
+	}
+
+	for i, later := range configs {
+		for _, earlier := range configs[:i] {
+			if shadows(&earlier.Config, &later.Config) {
+				report.Errors = append(report.Errors, ValidationError{
+					File:    later.File,
+					Config:  later.Config.CounterKey(),
+					Field:   "pattern",
+					Message: fmt.Sprintf("unreachable, every request is matched first by %q", earlier.Config.CounterKey()+locationSuffix(earlier.File)),
+				})
+				break
+			}
+		}
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+func locationSuffix(file string) string {
+	if file == "" {
+		return ""
+	}
+	return " in " + file
+}
+
+// shadows reports whether earlier provably matches every request later
+// would. That is the case when later matches a few paths only, all of which
+// earlier matches, or when earlier matches everything starting with (or, when
+// unanchored, containing) a literal that all of later's paths start with.
+// Anything that cannot be proven is not reported.
+func shadows(earlier, later *PathConfig) bool {
+	// Temporary configs are matched first and go away, neither hides the other
+	if earlier.Temporary() || later.Temporary() {
+		return false
+	}
+	if len(earlier.Methods) > 0 {
+		if len(later.Methods) == 0 {
+			return false
+		}
+		for _, method := range later.Methods {
+			if !contains(earlier.Methods, method) {
+				return false
+			}
+		}
+	}
+
+	earlierRe, err := regexp.Compile(earlier.Pattern)
+	if err != nil {
+		return false
+	}
+	laterRe, err := syntax.Parse(later.Pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	laterRe = laterRe.Simplify()
+
+	if paths, ok := anchoredPaths(laterRe); ok {
+		for _, path := range paths {
+			if !earlierRe.MatchString(path) {
+				return false
+			}
+		}
+		return true
+	}
+
+	earlierParsed, err := syntax.Parse(earlier.Pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	literal, anchored, ok := catchAllPrefix(earlierParsed.Simplify())
+	if !ok {
+		return false
+	}
+	prefix, laterAnchored := literalPrefix(laterRe)
+	if anchored {
+		return laterAnchored && strings.HasPrefix(prefix, literal)
+	}
+	return strings.Contains(prefix, literal)
+}
+
+// concatSubs returns the parts of a concatenation, or re itself
+func concatSubs(re *syntax.Regexp) []*syntax.Regexp {
+	if re.Op == syntax.OpConcat {
+		return re.Sub
+	}
+	return []*syntax.Regexp{re}
+}
+
+// anchoredPaths returns every path a pattern anchored at both ends matches,
+// false when there are more than shadowPathLimit of them
+func anchoredPaths(re *syntax.Regexp) ([]string, bool) {
+	subs := concatSubs(re)
+	if len(subs) < 2 || subs[0].Op != syntax.OpBeginText || subs[len(subs)-1].Op != syntax.OpEndText {
+		return nil, false
+	}
+	paths := []string{""}
+	for _, sub := range subs[1 : len(subs)-1] {
+		strs, ok := finiteStrings(sub)
+		if !ok {
+			return nil, false
+		}
+		if paths, ok = crossStrings(paths, strs); !ok {
+			return nil, false
+		}
+	}
+	return paths, true
+}
+
+// finiteStrings returns every string re matches, false when they are too
+// many or re contains anchors
+func finiteStrings(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+	case syntax.OpCharClass:
+		var strs []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(strs) == shadowPathLimit {
+					return nil, false
+				}
+				strs = append(strs, string(r))
+			}
+		}
+		return strs, true
+	case syntax.OpCapture:
+		return finiteStrings(re.Sub[0])
+	case syntax.OpQuest:
+		strs, ok := finiteStrings(re.Sub[0])
+		return append([]string{""}, strs...), ok && len(strs) < shadowPathLimit
+	case syntax.OpRepeat:
+		if re.Max < 0 {
+			return nil, false
+		}
+		sub, ok := finiteStrings(re.Sub[0])
+		if !ok {
+			return nil, false
+		}
+		var strs []string
+		repeated := []string{""}
+		for n := 0; n <= re.Max; n++ {
+			if n >= re.Min {
+				strs = append(strs, repeated...)
+			}
+			if repeated, ok = crossStrings(repeated, sub); !ok {
+				return nil, false
+			}
+		}
+		return strs, len(strs) <= shadowPathLimit
+	case syntax.OpConcat:
+		strs := []string{""}
+		for _, sub := range re.Sub {
+			next, ok := finiteStrings(sub)
+			if !ok {
+				return nil, false
+			}
+			if strs, ok = crossStrings(strs, next); !ok {
+				return nil, false
+			}
+		}
+		return strs, true
+	case syntax.OpAlternate:
+		var strs []string
+		for _, sub := range re.Sub {
+			next, ok := finiteStrings(sub)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, next...)
+		}
+		return strs, len(strs) <= shadowPathLimit
+	default:
+		return nil, false
+	}
+}
+
+// crossStrings concatenates every prefix with every suffix
+func crossStrings(prefixes, suffixes []string) ([]string, bool) {
+	if len(prefixes)*len(suffixes) > shadowPathLimit {
+		return nil, false
+	}
+	strs := make([]string, 0, len(prefixes)*len(suffixes))
+	for _, p := range prefixes {
+		for _, s := range suffixes {
+			strs = append(strs, p+s)
+		}
+	}
+	return strs, true
+}
+
+// catchAllPrefix recognizes patterns matching every path that starts with
+// literal, such as ^/api/ or ^/api/.*, and unanchored ones matching every
+// path containing it
+func catchAllPrefix(re *syntax.Regexp) (literal string, anchored bool, ok bool) {
+	subs := concatSubs(re)
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		anchored, subs = true, subs[1:]
+	}
+	for len(subs) > 0 && subs[0].Op == syntax.OpLiteral && subs[0].Flags&syntax.FoldCase == 0 {
+		literal += string(subs[0].Rune)
+		subs = subs[1:]
+	}
+	for _, sub := range subs {
+		anyRun := sub.Op == syntax.OpStar && (sub.Sub[0].Op == syntax.OpAnyChar || sub.Sub[0].Op == syntax.OpAnyCharNotNL)
+		if !anyRun && sub.Op != syntax.OpEmptyMatch {
+			return "", false, false
+		}
+	}
+	return literal, anchored, true
+}
+
+// literalPrefix returns the literal every match of re starts with and
+// whether re is anchored at the start
+func literalPrefix(re *syntax.Regexp) (prefix string, anchored bool) {
+	subs := concatSubs(re)
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		anchored, subs = true, subs[1:]
+	}
+	for len(subs) > 0 && subs[0].Op == syntax.OpLiteral && subs[0].Flags&syntax.FoldCase == 0 {
+		prefix += string(subs[0].Rune)
+		subs = subs[1:]
+	}
+	return prefix, anchored
+}
+
+// ValidateAdditions validates configs as if they were appended to existing,
+// the currently loaded configs, which are only checked for shadowing them
+func ValidateAdditions(existing, added []PathConfig) ValidationReport {
+	validated := make([]ValidatedConfig, len(added))
+	for i := range added {
+		validated[i] = ValidatedConfig{Config: added[i]}
+	}
+	report := ValidatePathConfigs(validated)
+
+	for i := range added {
+		for j := range existing {
+			if shadows(&existing[j], &added[i]) {
+				report.Errors = append(report.Errors, ValidationError{
+					Config:  added[i].CounterKey(),
+					Field:   "pattern",
+					Message: fmt.Sprintf("unreachable, every request is matched first by loaded config %q", existing[j].CounterKey()),
+				})
+				break
+			}
+		}
+	}
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// ValidateDir strictly decodes and validates every .json path config below
+// dir, in the order the server would load them
+func ValidateDir(dir string) (ValidationReport, error) {
+	var configs []ValidatedConfig
+	var decodeErrs []ValidationError
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		cfg, err := DecodePathConfigStrict(data)
+		if err != nil {
+			decodeErrs = append(decodeErrs, ValidationError{File: path, Message: err.Error()})
+			return nil
+		}
+		configs = append(configs, ValidatedConfig{File: path, Config: cfg})
+		return nil
+	})
+	if err != nil {
+		return ValidationReport{}, err
+	}
+
+	report := ValidatePathConfigs(configs)
+	report.Configs += len(decodeErrs)
+	report.Errors = append(decodeErrs, report.Errors...)
+	report.Valid = len(report.Errors) == 0
+	return report, nil
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePathConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     PathConfig
+		wantFields []string
+	}{
+		{
+			name:   "valid",
+			config: PathConfig{Pattern: "^/ok$", Methods: []string{"GET"}, Response: ResponseConfig{StatusCode: 201, Body: "template:{{.Path}}"}},
+		},
+		{
+			name:       "missing pattern",
+			config:     PathConfig{},
+			wantFields: []string{"pattern"},
+		},
+		{
+			name:       "invalid regex and method",
+			config:     PathConfig{Pattern: "^/(", Methods: []string{"get"}},
+			wantFields: []string{"pattern", "methods[0]"},
+		},
+		{
+			name:       "invalid status codes",
+			config:     PathConfig{Pattern: "^/a$", Response: ResponseConfig{StatusCode: 99}, ErrorResponse: &ResponseConfig{StatusCode: 600}},
+			wantFields: []string{"response.statusCode", "errorResponse.statusCode"},
+		},
+		{
+			name:       "broken template",
+			config:     PathConfig{Pattern: "^/a$", Response: ResponseConfig{Body: "template:{{.Path"}},
+			wantFields: []string{"response.body"},
+		},
+		{
+			name:       "errorEvery without errorResponse",
+			config:     PathConfig{Pattern: "^/a$", ErrorEvery: 3},
+			wantFields: []string{"errorEvery"},
+		},
+		{
+			name:       "proxy without scheme",
+			config:     PathConfig{Pattern: "^/a$", Proxy: &ProxyConfig{URL: "localhost:9000"}},
+			wantFields: []string{"proxy.url"},
+		},
+		{
+			name: "websocket rule and timer",
+			config: PathConfig{Pattern: "^/ws$", WebSocket: &WebSocketConfig{
+				Rules:    []WebSocketRule{{Match: "(", Reply: "template:{{"}},
+				Periodic: []WebSocketTimer{{Message: "tick"}},
+			}},
+			wantFields: []string{"websocket.rules[0].match", "websocket.rules[0].reply", "websocket.periodic[0].interval"},
+		},
+		{
+			name:       "negative ttl and maxUses",
+			config:     PathConfig{Pattern: "^/a$", TTL: Duration{Duration: -time.Second}, MaxUses: -1},
+			wantFields: []string{"ttl", "maxUses"},
+		},
+		{
+			name: "responses",
+			config: PathConfig{Pattern: "^/a$", ResponseOrder: "shuffle", Responses: []WeightedResponse{
+				{ResponseConfig: ResponseConfig{StatusCode: 42}},
+				{Weight: -1},
+			}},
+			wantFields: []string{"responses[0].statusCode", "responses[1].weight", "responseOrder"},
+		},
+		{
+			name:       "reserved name",
+			config:     PathConfig{Name: "export", Pattern: "^/export$"},
+			wantFields: []string{"name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidatePathConfig(&tt.config)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("fields = %v, want %v (%v)", fields, tt.wantFields, errs)
+			}
+		})
+	}
+}
+
+func TestShadowedConfigs(t *testing.T) {
+	tests := []struct {
+		name     string
+		earlier  PathConfig
+		later    PathConfig
+		shadowed bool
+	}{
+		{"catch all", PathConfig{Pattern: "^/api/.*"}, PathConfig{Pattern: "^/api/users$"}, true},
+		{"disjoint", PathConfig{Pattern: "^/api/orders$"}, PathConfig{Pattern: "^/api/users$"}, false},
+		{"narrower earlier", PathConfig{Pattern: "^/api/users/[0-9]+$"}, PathConfig{Pattern: "^/api/users/.*"}, false},
+		{"alternation covered", PathConfig{Pattern: "^/(a|b|c)$"}, PathConfig{Pattern: "^/(a|b)$"}, true},
+		{"alternation not covered", PathConfig{Pattern: "^/(a|b)$"}, PathConfig{Pattern: "^/(a|c)$"}, false},
+		{"unanchored later", PathConfig{Pattern: "^/api/.*"}, PathConfig{Pattern: "/api/x"}, false},
+		{"methods covered", PathConfig{Pattern: "^/a$", Methods: []string{"GET", "POST"}}, PathConfig{Pattern: "^/a$", Methods: []string{"GET"}}, true},
+		{"methods not covered", PathConfig{Pattern: "^/a$", Methods: []string{"GET"}}, PathConfig{Pattern: "^/a$"}, false},
+		{"prefix covered", PathConfig{Pattern: "^/api"}, PathConfig{Pattern: "^/api/users/[0-9]+$"}, true},
+		{"unanchored literal covered", PathConfig{Pattern: "users"}, PathConfig{Pattern: "^/api/users/.*"}, true},
+		{"one path of a class", PathConfig{Pattern: "^/users/a"}, PathConfig{Pattern: "^/users/[a-z]+$"}, false},
+		{"one path of digits", PathConfig{Pattern: "^/items/0"}, PathConfig{Pattern: "^/items/[0-9]+$"}, false},
+		{"one path of any", PathConfig{Pattern: "^/a/x"}, PathConfig{Pattern: "^/a/.+$"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ValidatePathConfigs([]ValidatedConfig{{Config: tt.earlier}, {Config: tt.later}})
+			if report.Valid == tt.shadowed {
+				t.Errorf("valid = %v, want shadowed = %v: %v", report.Valid, tt.shadowed, report.Errors)
+			}
+		})
+	}
+}
+
+func TestSamplePaths(t *testing.T) {
+	for _, pattern := range []string{`^/users/[0-9]+/orders$`, `^/(a|b)/x?y*$`, `^/files/\w{2,4}\.json$`, `(?i)^/ABC$`} {
+		samples := SamplePaths(pattern, 10)
+		if len(samples) == 0 {
+			t.Errorf("no samples for %s", pattern)
+		}
+		re := regexp.MustCompile(pattern)
+		for _, sample := range samples {
+			if !re.MatchString(sample) {
+				t.Errorf("sample %q does not match %s", sample, pattern)
+			}
+		}
+	}
+}
+
+func TestSamplePath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		wantOK  bool
+	}{
+		{pattern: "^/users$", want: "/users", wantOK: true},
+		{pattern: `^/users/[0-9]+/orders/\w+$`, want: "/users/0/orders/a", wantOK: true},
+		{pattern: "^/api/(v1|v2)/items/?$", want: "/api/v1/items", wantOK: true},
+		{pattern: "^/files/.*", want: "/files/", wantOK: true},
+		{pattern: "users", want: "/users", wantOK: true},
+		{pattern: `^/a\bb$`, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			pm := NewPathMatcher()
+			if err := pm.Add(&PathConfig{Pattern: tt.pattern}); err != nil {
+				t.Fatal(err)
+			}
+			pc := pm.GetAllConfigs()[0]
+			got, ok := pc.SamplePath()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("SamplePath() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a-catch-all.json": `{"name": "all", "pattern": "^/api/.*"}`,
+		"b-users.json":     `{"name": "users", "pattern": "^/api/users$"}`,
+		"c-unknown.json":   `{"name": "typo", "pattern": "^/x$", "statusCode": 200}`,
+		"d-type.json":      "{\n  \"pattern\": \"^/y$\",\n  \"errorEvery\": \"3\"\n}",
+		"notes.txt":        "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := ValidateDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Valid || report.Configs != 4 || len(report.Errors) != 3 {
+		t.Fatalf("report = %+v, want 4 configs and 3 errors", report)
+	}
+
+	want := []string{
+		`c-unknown.json: json: unknown field "statusCode"`,
+		`d-type.json: line 3, column 20: field errorEvery`,
+		`b-users.json: users: pattern: unreachable`,
+	}
+	for i, e := range report.Errors {
+		if !strings.Contains(e.Error(), want[i]) {
+			t.Errorf("error %d = %q, want it to contain %q", i, e.Error(), want[i])
+		}
+	}
+}
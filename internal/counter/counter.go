@@ -1,16 +1,31 @@
 package counter
 
 import (
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"echo-server/pkg/logger"
 )
 
 type Counter struct {
-	globalCount uint64
-	pathCounts  sync.Map
-	mu          sync.RWMutex
+	counts *counts
+	rate   *window
+	mu     sync.RWMutex
+}
+
+// counts holds the counter values. Restore and Reset build a new one and
+// swap it in, so readers never see a half restored state.
+type counts struct {
+	globalCount  uint64
+	pathCounts   sync.Map
+	configCounts sync.Map
+	methodCounts sync.Map
+	statusCounts sync.Map
+	positions    sync.Map
 }
 
 var (
@@ -20,53 +35,216 @@ var (
 
 func GetGlobalCounter() *Counter {
 	once.Do(func() {
-		globalCounter = &Counter{}
+		globalCounter = New()
 	})
 	return globalCounter
 }
 
+// New creates an empty Counter
+func New() *Counter {
+	return &Counter{counts: &counts{}, rate: newWindow()}
+}
+
+func (c *Counter) current() *counts {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.counts
+}
+
+func (c *Counter) swap(next *counts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts = next
+}
+
 func (c *Counter) Increment() uint64 {
-	return atomic.AddUint64(&c.globalCount, 1)
+	c.rate.record(time.Now())
+	return atomic.AddUint64(&c.current().globalCount, 1)
 }
 
 func (c *Counter) GetCount() uint64 {
-	return atomic.LoadUint64(&c.globalCount)
+	return atomic.LoadUint64(&c.current().globalCount)
 }
 
 func (c *Counter) IncrementPath(path string) uint64 {
-	var count uint64
-	actual, _ := c.pathCounts.LoadOrStore(path, &count)
-	return atomic.AddUint64(actual.(*uint64), 1)
+	return incrementKey(&c.current().pathCounts, path)
 }
 
 func (c *Counter) GetPathCount(path string) uint64 {
-	if count, ok := c.pathCounts.Load(path); ok {
-		return atomic.LoadUint64(count.(*uint64))
-	}
-	return 0
+	return loadKey(&c.current().pathCounts, path)
 }
 
 func (c *Counter) GetAllPathCounts() map[string]uint64 {
-	counts := make(map[string]uint64)
-	c.pathCounts.Range(func(key, value interface{}) bool {
-		counts[key.(string)] = atomic.LoadUint64(value.(*uint64))
-		return true
-	})
-	return counts
+	return loadAll(&c.current().pathCounts)
+}
+
+// IncrementConfig counts a request matched by the named path config
+func (c *Counter) IncrementConfig(name string) uint64 {
+	return incrementKey(&c.current().configCounts, name)
+}
+
+func (c *Counter) GetConfigCount(name string) uint64 {
+	return loadKey(&c.current().configCounts, name)
+}
+
+func (c *Counter) GetAllConfigCounts() map[string]uint64 {
+	return loadAll(&c.current().configCounts)
+}
+
+func (c *Counter) IncrementMethod(method string) uint64 {
+	return incrementKey(&c.current().methodCounts, method)
+}
+
+func (c *Counter) GetMethodCount(method string) uint64 {
+	return loadKey(&c.current().methodCounts, method)
+}
+
+func (c *Counter) GetAllMethodCounts() map[string]uint64 {
+	return loadAll(&c.current().methodCounts)
+}
+
+// IncrementStatus counts a response by status code
+func (c *Counter) IncrementStatus(status int) uint64 {
+	return incrementKey(&c.current().statusCounts, strconv.Itoa(status))
+}
+
+func (c *Counter) GetAllStatusCounts() map[string]uint64 {
+	return loadAll(&c.current().statusCounts)
+}
+
+// IncrementPosition advances the named config to its next entry of Responses
+// and returns how many it served so far
+func (c *Counter) IncrementPosition(name string) uint64 {
+	return incrementKey(&c.current().positions, name)
+}
+
+func (c *Counter) GetPosition(name string) uint64 {
+	return loadKey(&c.current().positions, name)
+}
+
+func (c *Counter) GetAllPositions() map[string]uint64 {
+	return loadAll(&c.current().positions)
+}
+
+// Rates returns the number of requests seen in the last minute and the last
+// five minutes, keyed "1m" and "5m"
+func (c *Counter) Rates() map[string]uint64 {
+	now := time.Now()
+	return map[string]uint64{
+		"1m": c.rate.count(now, time.Minute),
+		"5m": c.rate.count(now, 5*time.Minute),
+	}
 }
 
 func (c *Counter) ResetPath(path string) {
-	if count, ok := c.pathCounts.Load(path); ok {
+	if count, ok := c.current().pathCounts.Load(path); ok {
 		atomic.StoreUint64(count.(*uint64), 0)
 		logger.Info("Reset counter for path: %s", path)
 	}
 }
 
+// ResetPosition starts the Responses of the named config over
+func (c *Counter) ResetPosition(name string) {
+	if count, ok := c.current().positions.Load(name); ok {
+		atomic.StoreUint64(count.(*uint64), 0)
+		logger.Info("Reset response position for config: %s", name)
+	}
+}
+
+func (c *Counter) ResetConfig(name string) {
+	if count, ok := c.current().configCounts.Load(name); ok {
+		atomic.StoreUint64(count.(*uint64), 0)
+		logger.Info("Reset counter for config: %s", name)
+	}
+}
+
+// ResetPathPrefix resets every path counter starting with prefix and returns
+// how many were reset
+func (c *Counter) ResetPathPrefix(prefix string) int {
+	n := resetMatching(&c.current().pathCounts, func(path string) bool { return strings.HasPrefix(path, prefix) })
+	logger.Info("Reset %d counters for path prefix: %s", n, prefix)
+	return n
+}
+
+// ResetPathRegex resets every path counter matching re and returns how many
+// were reset
+func (c *Counter) ResetPathRegex(re *regexp.Regexp) int {
+	n := resetMatching(&c.current().pathCounts, re.MatchString)
+	logger.Info("Reset %d counters for path regex: %s", n, re)
+	return n
+}
+
+// Set stores an arbitrary global count
+func (c *Counter) Set(value uint64) {
+	atomic.StoreUint64(&c.current().globalCount, value)
+	logger.Info("Set global counter to %d", value)
+}
+
+// SetPath stores an arbitrary count for path, e.g. to position ErrorEvery
+func (c *Counter) SetPath(path string, value uint64) {
+	setKey(&c.current().pathCounts, path, value)
+	logger.Info("Set counter for path %s to %d", path, value)
+}
+
+func (c *Counter) SetConfig(name string, value uint64) {
+	setKey(&c.current().configCounts, name, value)
+	logger.Info("Set counter for config %s to %d", name, value)
+}
+
+// SetPosition moves the named config to an arbitrary entry of Responses, the
+// next request gets entry value+1
+func (c *Counter) SetPosition(name string, value uint64) {
+	setKey(&c.current().positions, name, value)
+	logger.Info("Set response position for config %s to %d", name, value)
+}
+
+func (c *Counter) SetMethod(method string, value uint64) {
+	setKey(&c.current().methodCounts, method, value)
+	logger.Info("Set counter for method %s to %d", method, value)
+}
+
 func (c *Counter) Reset() {
-	atomic.StoreUint64(&c.globalCount, 0)
-	c.pathCounts.Range(func(key, value interface{}) bool {
-		c.pathCounts.Delete(key)
+	c.swap(&counts{})
+	c.rate.reset()
+	logger.Info("Reset all counters")
+}
+
+func incrementKey(m *sync.Map, key string) uint64 {
+	var count uint64
+	actual, _ := m.LoadOrStore(key, &count)
+	return atomic.AddUint64(actual.(*uint64), 1)
+}
+
+func setKey(m *sync.Map, key string, value uint64) {
+	var count uint64
+	actual, _ := m.LoadOrStore(key, &count)
+	atomic.StoreUint64(actual.(*uint64), value)
+}
+
+func resetMatching(m *sync.Map, match func(string) bool) int {
+	n := 0
+	m.Range(func(key, value interface{}) bool {
+		if match(key.(string)) {
+			atomic.StoreUint64(value.(*uint64), 0)
+			n++
+		}
 		return true
 	})
-	logger.Info("Reset all counters")
+	return n
+}
+
+func loadKey(m *sync.Map, key string) uint64 {
+	if count, ok := m.Load(key); ok {
+		return atomic.LoadUint64(count.(*uint64))
+	}
+	return 0
+}
+
+func loadAll(m *sync.Map) map[string]uint64 {
+	counts := make(map[string]uint64)
+	m.Range(func(key, value interface{}) bool {
+		counts[key.(string)] = atomic.LoadUint64(value.(*uint64))
+		return true
+	})
+	return counts
 }
@@ -1,8 +1,10 @@
 package counter
 
 import (
+	"regexp"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestCounterThreadSafety(t *testing.T) {
@@ -51,3 +53,90 @@ func TestCounterThreadSafety(t *testing.T) {
 		t.Errorf("Path counter = %d, want %d", count, expectedCount)
 	}
 }
+
+func TestCounterKeys(t *testing.T) {
+	c := New()
+
+	c.IncrementConfig("users")
+	c.IncrementConfig("users")
+	c.IncrementMethod("GET")
+	c.IncrementStatus(503)
+	c.IncrementStatus(503)
+	c.IncrementStatus(200)
+
+	if got := c.GetConfigCount("users"); got != 2 {
+		t.Errorf("Config count = %d, want 2", got)
+	}
+	if got := c.GetMethodCount("GET"); got != 1 {
+		t.Errorf("Method count = %d, want 1", got)
+	}
+	if got := c.GetAllStatusCounts(); got["503"] != 2 || got["200"] != 1 {
+		t.Errorf("Status counts = %v", got)
+	}
+
+	c.ResetConfig("users")
+	if got := c.GetConfigCount("users"); got != 0 {
+		t.Errorf("Config count after reset = %d, want 0", got)
+	}
+
+	c.Reset()
+	if len(c.GetAllMethodCounts()) != 0 || len(c.GetAllStatusCounts()) != 0 {
+		t.Error("Expected Reset to clear method and status counts")
+	}
+}
+
+func TestWindow(t *testing.T) {
+	w := newWindow()
+	now := time.Unix(1_000_000, 0)
+
+	w.record(now.Add(-4 * time.Minute))
+	w.record(now.Add(-2 * time.Minute))
+	w.record(now.Add(-30 * time.Second))
+	w.record(now)
+	// Outside the five minute window
+	w.record(now.Add(-6 * time.Minute))
+
+	if got := w.count(now, time.Minute); got != 2 {
+		t.Errorf("1m count = %d, want 2", got)
+	}
+	if got := w.count(now, 5*time.Minute); got != 4 {
+		t.Errorf("5m count = %d, want 4", got)
+	}
+	// Buckets from a previous lap of the ring are not counted
+	if got := w.count(now.Add(10*time.Minute), 5*time.Minute); got != 0 {
+		t.Errorf("Count after 10m = %d, want 0", got)
+	}
+}
+
+func TestCounterSetAndPatternReset(t *testing.T) {
+	c := New()
+	for _, path := range []string{"/api/a", "/api/b", "/other"} {
+		c.IncrementPath(path)
+	}
+
+	if n := c.ResetPathPrefix("/api/"); n != 2 {
+		t.Errorf("ResetPathPrefix reset %d counters, want 2", n)
+	}
+	if c.GetPathCount("/api/a") != 0 || c.GetPathCount("/other") != 1 {
+		t.Errorf("Path counts after prefix reset = %v", c.GetAllPathCounts())
+	}
+
+	c.SetPath("/api/a", 5)
+	c.SetPath("/new", 3)
+	if n := c.ResetPathRegex(regexp.MustCompile(`^/api/a$`)); n != 1 {
+		t.Errorf("ResetPathRegex reset %d counters, want 1", n)
+	}
+	if got := c.GetPathCount("/new"); got != 3 {
+		t.Errorf("SetPath on a new path = %d, want 3", got)
+	}
+	if got := c.IncrementPath("/new"); got != 4 {
+		t.Errorf("Increment after SetPath = %d, want 4", got)
+	}
+
+	c.Set(41)
+	c.SetConfig("users", 2)
+	c.SetMethod("GET", 7)
+	if c.Increment() != 42 || c.GetConfigCount("users") != 2 || c.GetMethodCount("GET") != 7 {
+		t.Errorf("Set values = %d/%d/%d, want 42/2/7", c.GetCount(), c.GetConfigCount("users"), c.GetMethodCount("GET"))
+	}
+}
@@ -0,0 +1,212 @@
+package counter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"echo-server/pkg/logger"
+)
+
+// Snapshot is a point in time copy of all counter values. Rates are not
+// included, they describe recent traffic of a single instance.
+type Snapshot struct {
+	Time     time.Time         `json:"time"`
+	Global   uint64            `json:"global"`
+	Paths    map[string]uint64 `json:"paths"`
+	Configs  map[string]uint64 `json:"configs"`
+	Methods  map[string]uint64 `json:"methods"`
+	Statuses map[string]uint64 `json:"statuses"`
+	// Positions are how many entries of Responses each config served
+	Positions map[string]uint64 `json:"positions,omitempty"`
+	// Namespaces holds the counters of each namespace in snapshot files,
+	// see Snapshotter.IncludeNamespaces
+	Namespaces map[string]Snapshot `json:"namespaces,omitempty"`
+}
+
+// Snapshot returns the current counter values
+func (c *Counter) Snapshot() Snapshot {
+	values := c.current()
+	return Snapshot{
+		Time:      time.Now().UTC(),
+		Global:    atomic.LoadUint64(&values.globalCount),
+		Paths:     loadAll(&values.pathCounts),
+		Configs:   loadAll(&values.configCounts),
+		Methods:   loadAll(&values.methodCounts),
+		Statuses:  loadAll(&values.statusCounts),
+		Positions: loadAll(&values.positions),
+	}
+}
+
+// Validate reports whether the snapshot can be restored
+func (s Snapshot) Validate() error {
+	for status := range s.Statuses {
+		if _, err := strconv.Atoi(status); err != nil {
+			return fmt.Errorf("invalid status code in snapshot: %s", status)
+		}
+	}
+	for name, ns := range s.Namespaces {
+		if err := ns.Validate(); err != nil {
+			return fmt.Errorf("namespace %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Restore replaces all counter values with the ones in the snapshot in one
+// step, requests counted meanwhile see either the old or the restored values
+func (c *Counter) Restore(s Snapshot) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	restored := &counts{globalCount: s.Global}
+	storeAll(&restored.pathCounts, s.Paths)
+	storeAll(&restored.configCounts, s.Configs)
+	storeAll(&restored.methodCounts, s.Methods)
+	storeAll(&restored.statusCounts, s.Statuses)
+	storeAll(&restored.positions, s.Positions)
+	c.swap(restored)
+	c.rate.reset()
+	logger.Info("Restored counters from snapshot taken at %s (global: %d)", s.Time.Format(time.RFC3339), s.Global)
+	return nil
+}
+
+func storeAll(m *sync.Map, values map[string]uint64) {
+	for key, value := range values {
+		count := value
+		m.Store(key, &count)
+	}
+}
+
+// SaveSnapshot writes the snapshot to file. The file is replaced atomically
+// so a crash mid write never leaves a truncated snapshot behind.
+func SaveSnapshot(file string, s Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot
+func LoadSnapshot(file string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("parsing snapshot %s: %w", file, err)
+	}
+	return s, nil
+}
+
+// NamespaceCounters are the counters of namespaces, which a Snapshotter
+// saves next to its own counter
+type NamespaceCounters interface {
+	// CounterSnapshots returns the counters of every namespace by name
+	CounterSnapshots() map[string]Snapshot
+	// RestoreCounters restores the counters of the namespaces by name
+	RestoreCounters(snapshots map[string]Snapshot) error
+}
+
+// Snapshotter periodically saves a counter to a file
+type Snapshotter struct {
+	counter    *Counter
+	namespaces NamespaceCounters
+	file       string
+	interval   time.Duration
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewSnapshotter(c *Counter, file string, interval time.Duration) *Snapshotter {
+	return &Snapshotter{
+		counter:  c,
+		file:     file,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// IncludeNamespaces saves and restores the counters of namespaces along
+// with the snapshotter's counter. Call it before Restore.
+func (s *Snapshotter) IncludeNamespaces(namespaces NamespaceCounters) {
+	s.namespaces = namespaces
+}
+
+// Restore loads the snapshot file into the counter when it exists
+func (s *Snapshotter) Restore() error {
+	snapshot, err := LoadSnapshot(s.file)
+	if os.IsNotExist(err) {
+		logger.Info("No counter snapshot at %s, starting from zero", s.file)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.counter.Restore(snapshot); err != nil {
+		return err
+	}
+	if s.namespaces != nil && len(snapshot.Namespaces) > 0 {
+		return s.namespaces.RestoreCounters(snapshot.Namespaces)
+	}
+	return nil
+}
+
+// snapshot returns the values to save
+func (s *Snapshotter) snapshot() Snapshot {
+	snapshot := s.counter.Snapshot()
+	if s.namespaces != nil {
+		snapshot.Namespaces = s.namespaces.CounterSnapshots()
+	}
+	return snapshot
+}
+
+// Start saves a snapshot every interval until Stop is called
+func (s *Snapshotter) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if err := SaveSnapshot(s.file, s.snapshot()); err != nil {
+					logger.Error("Failed to save counter snapshot: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends periodic snapshots and writes a final one
+func (s *Snapshotter) Stop() error {
+	close(s.stop)
+	<-s.done
+	return SaveSnapshot(s.file, s.snapshot())
+}
@@ -0,0 +1,157 @@
+package counter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	c := New()
+	c.Increment()
+	c.Increment()
+	c.IncrementPath("/a")
+	c.IncrementConfig("cfg")
+	c.IncrementMethod("GET")
+	c.IncrementStatus(200)
+
+	file := filepath.Join(t.TempDir(), "counters.json")
+	if err := SaveSnapshot(file, c.Snapshot()); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	loaded, err := LoadSnapshot(file)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+
+	restored := New()
+	restored.IncrementPath("/stale")
+	if err := restored.Restore(loaded); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if got := restored.GetCount(); got != 2 {
+		t.Errorf("global = %d, want 2", got)
+	}
+	if got := restored.GetPathCount("/a"); got != 1 {
+		t.Errorf("path /a = %d, want 1", got)
+	}
+	if got := restored.GetPathCount("/stale"); got != 0 {
+		t.Errorf("path /stale = %d, want 0 after restore", got)
+	}
+	if got := restored.GetConfigCount("cfg"); got != 1 {
+		t.Errorf("config cfg = %d, want 1", got)
+	}
+	if got := restored.GetAllStatusCounts()["200"]; got != 1 {
+		t.Errorf("status 200 = %d, want 1", got)
+	}
+
+	// Restored counters keep counting
+	if got := restored.IncrementPath("/a"); got != 2 {
+		t.Errorf("path /a after increment = %d, want 2", got)
+	}
+
+	if err := restored.Restore(Snapshot{Statuses: map[string]uint64{"ok": 1}}); err == nil {
+		t.Error("Restore with invalid status code should fail")
+	}
+}
+
+func TestRestoreIsAtomic(t *testing.T) {
+	c := New()
+	snapshots := []Snapshot{
+		{Global: 1, Paths: map[string]uint64{"/a": 1, "/b": 1}},
+		{Global: 2, Paths: map[string]uint64{"/a": 2, "/b": 2}},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 2000 {
+			if err := c.Restore(snapshots[i%2]); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		s := c.Snapshot()
+		if s.Global != 0 && (s.Paths["/a"] != s.Global || s.Paths["/b"] != s.Global) {
+			t.Fatalf("partially restored snapshot %+v", s)
+		}
+	}
+}
+
+func TestSnapshotter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "counters.json")
+	c := New()
+
+	s := NewSnapshotter(c, file, 10*time.Millisecond)
+	if err := s.Restore(); err != nil {
+		t.Fatalf("Restore without a snapshot file: %v", err)
+	}
+	s.Start()
+	c.Increment()
+	c.IncrementPath("/a")
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	next := New()
+	if err := NewSnapshotter(next, file, time.Minute).Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if next.GetCount() != 1 || next.GetPathCount("/a") != 1 {
+		t.Errorf("restored global = %d, path = %d, want 1 and 1", next.GetCount(), next.GetPathCount("/a"))
+	}
+}
+
+// namespaceCounters keeps namespace counters in a map
+type namespaceCounters map[string]*Counter
+
+func (n namespaceCounters) CounterSnapshots() map[string]Snapshot {
+	snapshots := make(map[string]Snapshot)
+	for name, c := range n {
+		snapshots[name] = c.Snapshot()
+	}
+	return snapshots
+}
+
+func (n namespaceCounters) RestoreCounters(snapshots map[string]Snapshot) error {
+	for name, snapshot := range snapshots {
+		n[name] = New()
+		if err := n[name].Restore(snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSnapshotterNamespaces(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "counters.json")
+	team := New()
+	team.IncrementConfig("users")
+
+	s := NewSnapshotter(New(), file, time.Minute)
+	s.IncludeNamespaces(namespaceCounters{"team": team})
+	s.Start()
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	restored := namespaceCounters{}
+	next := NewSnapshotter(New(), file, time.Minute)
+	next.IncludeNamespaces(restored)
+	if err := next.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if c, ok := restored["team"]; !ok || c.GetConfigCount("users") != 1 {
+		t.Errorf("restored namespaces = %v, want team with users = 1", restored)
+	}
+}
@@ -0,0 +1,59 @@
+package counter
+
+import (
+	"sync"
+	"time"
+)
+
+// windowSize is the longest period a window can report on, in seconds
+const windowSize = 300
+
+// window counts events in one second buckets over the last windowSize
+// seconds. Buckets are reused round robin and cleared when their second has
+// passed.
+type window struct {
+	mu      sync.Mutex
+	buckets [windowSize]uint64
+	seconds [windowSize]int64
+}
+
+func newWindow() *window {
+	return &window{}
+}
+
+func (w *window) record(now time.Time) {
+	sec := now.Unix()
+	idx := sec % windowSize
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.seconds[idx] != sec {
+		w.seconds[idx] = sec
+		w.buckets[idx] = 0
+	}
+	w.buckets[idx]++
+}
+
+// count returns the events recorded during the last period, including the
+// current second
+func (w *window) count(now time.Time, period time.Duration) uint64 {
+	sec := now.Unix()
+	oldest := sec - int64(period/time.Second) + 1
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var total uint64
+	for i := range w.buckets {
+		if w.seconds[i] >= oldest && w.seconds[i] <= sec {
+			total += w.buckets[i]
+		}
+	}
+	return total
+}
+
+func (w *window) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buckets = [windowSize]uint64{}
+	w.seconds = [windowSize]int64{}
+}
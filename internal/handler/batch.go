@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"echo-server/internal/config"
+	"echo-server/pkg/logger"
+)
+
+// Batch operations
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// BatchRequest is a list of operations applied all together or not at all
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation creates a config, or updates or deletes the config called
+// Name. Updates default Name to the config's name and keep the position of
+// the config they replace.
+type BatchOperation struct {
+	Op     string             `json:"op"`
+	Name   string             `json:"name,omitempty"`
+	Config *config.PathConfig `json:"config,omitempty"`
+}
+
+// BatchOperationResult is the outcome of one operation, Errors is empty when
+// it succeeded
+type BatchOperationResult struct {
+	Index  int                      `json:"index"`
+	Op     string                   `json:"op"`
+	Name   string                   `json:"name"`
+	OK     bool                     `json:"ok"`
+	Errors []config.ValidationError `json:"errors,omitempty"`
+}
+
+// BatchResponse reports whether a batch was applied and the outcome of each
+// operation. When one operation fails none is applied.
+type BatchResponse struct {
+	Applied bool                   `json:"applied"`
+	DryRun  bool                   `json:"dryRun,omitempty"`
+	Configs int                    `json:"configs"`
+	Results []BatchOperationResult `json:"results"`
+}
+
+var errBatchFailed = errors.New("batch has failed operations")
+
+// applyBatch applies ops to a copy of configs in order, so later operations
+// see the effect of earlier ones
+func applyBatch(configs []config.PathConfig, ops []BatchOperation) ([]config.PathConfig, []BatchOperationResult, bool) {
+	next := append([]config.PathConfig(nil), configs...)
+	results := make([]BatchOperationResult, len(ops))
+	ok := true
+
+	indexOf := func(name string) int {
+		for i := range next {
+			if next[i].Name == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for i, op := range ops {
+		result := BatchOperationResult{Index: i, Op: op.Op, Name: op.Name}
+		fail := func(field, format string, args ...interface{}) {
+			result.Errors = append(result.Errors, config.ValidationError{Config: result.Name, Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+		if result.Name == "" && op.Config != nil {
+			result.Name = op.Config.Name
+		}
+
+		switch op.Op {
+		case OpCreate, OpUpdate:
+			if op.Config == nil {
+				fail("config", "config is required")
+				break
+			}
+			pc := *op.Config
+			result.Errors = append(result.Errors, config.ValidatePathConfig(&pc)...)
+			existing := indexOf(result.Name)
+			switch {
+			case op.Op == OpCreate && result.Name != "" && existing >= 0:
+				fail("name", "a config named %q already exists", result.Name)
+			case op.Op == OpUpdate && result.Name == "":
+				fail("name", "name is required")
+			case op.Op == OpUpdate && existing < 0:
+				fail("name", "no config named %q", result.Name)
+			}
+			if len(result.Errors) > 0 {
+				break
+			}
+			if op.Op == OpCreate {
+				next = append(next, pc)
+			} else {
+				if pc.Name == "" {
+					pc.Name = result.Name
+				}
+				next[existing] = pc
+			}
+		case OpDelete:
+			existing := -1
+			if result.Name != "" {
+				existing = indexOf(result.Name)
+			}
+			if existing < 0 {
+				fail("name", "no config named %q", result.Name)
+				break
+			}
+			next = append(next[:existing], next[existing+1:]...)
+		default:
+			fail("op", "unknown operation %q, expected create, update or delete", op.Op)
+		}
+
+		result.OK = len(result.Errors) == 0
+		ok = ok && result.OK
+		results[i] = result
+	}
+	return next, results, ok
+}
+
+// handleBatch applies a list of create, update and delete operations at
+// once. All operations are validated first; when one fails nothing changes
+// and 422 is returned with the result of every operation. Concurrent
+// requests never see a partially applied batch. With ?dryRun nothing changes.
+func (h *ConfigurationHandler) handleBatch(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var batch BatchRequest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&batch); err != nil {
+		http.Error(w, "Invalid batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := BatchResponse{DryRun: r.URL.Query().Has("dryRun")}
+	if resp.DryRun {
+		next, results, _ := applyBatch(h.serverConfig(r).PathMatcher.GetAllConfigs(), batch.Operations)
+		resp.Configs, resp.Results = len(next), results
+	} else {
+		_, err = h.updateConfigs(r, func(configs []config.PathConfig) ([]config.PathConfig, error) {
+			next, results, ok := applyBatch(configs, batch.Operations)
+			resp.Configs, resp.Results = len(next), results
+			if !ok {
+				return nil, errBatchFailed
+			}
+			return next, nil
+		})
+		resp.Applied = err == nil
+		if err != nil && !errors.Is(err, errBatchFailed) {
+			logger.Error("Failed to apply config batch: %v", err)
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if resp.Applied {
+			logger.Info("Applied config batch of %d operations", len(batch.Operations))
+		}
+	}
+
+	status := http.StatusOK
+	for _, result := range resp.Results {
+		if !result.OK {
+			status = http.StatusUnprocessableEntity
+			break
+		}
+	}
+	if resp.Results == nil {
+		resp.Results = []BatchOperationResult{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("Failed to encode batch response: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"echo-server/internal/config"
+)
+
+func TestConfigBatch(t *testing.T) {
+	newHandler := func(t *testing.T) (*ConfigurationHandler, *config.ConfigManager) {
+		cm := config.NewConfigManager()
+		cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher()})
+		for _, pc := range []config.PathConfig{
+			{Name: "users", Pattern: "^/users$"},
+			{Name: "health", Pattern: "^/health$"},
+		} {
+			if err := cm.UpdatePathConfig(pc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return NewConfigurationHandler(cm), cm
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantStatus int
+		wantOK     []bool
+		wantNames  []string
+	}{
+		{
+			name:   "applied",
+			target: "/config/batch",
+			body: `{"operations": [
+				{"op": "create", "config": {"name": "orders", "pattern": "^/orders$"}},
+				{"op": "update", "name": "users", "config": {"pattern": "^/users/v2$"}},
+				{"op": "delete", "name": "health"}
+			]}`,
+			wantStatus: http.StatusOK,
+			wantOK:     []bool{true, true, true},
+			wantNames:  []string{"users", "orders"},
+		},
+		{
+			name:   "later operations see earlier ones",
+			target: "/config/batch",
+			body: `{"operations": [
+				{"op": "create", "config": {"name": "orders", "pattern": "^/orders$"}},
+				{"op": "delete", "name": "orders"}
+			]}`,
+			wantStatus: http.StatusOK,
+			wantOK:     []bool{true, true},
+			wantNames:  []string{"users", "health"},
+		},
+		{
+			name:   "one failure applies nothing",
+			target: "/config/batch",
+			body: `{"operations": [
+				{"op": "create", "config": {"name": "orders", "pattern": "^/orders$"}},
+				{"op": "create", "config": {"name": "broken", "pattern": "("}},
+				{"op": "delete", "name": "missing"},
+				{"op": "create", "config": {"name": "users", "pattern": "^/u$"}},
+				{"op": "rename", "name": "users"}
+			]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantOK:     []bool{true, false, false, false, false},
+			wantNames:  []string{"users", "health"},
+		},
+		{
+			name:       "dry run",
+			target:     "/config/batch?dryRun",
+			body:       `{"operations": [{"op": "delete", "name": "users"}]}`,
+			wantStatus: http.StatusOK,
+			wantOK:     []bool{true},
+			wantNames:  []string{"users", "health"},
+		},
+		{
+			name:       "malformed",
+			target:     "/config/batch",
+			body:       `[{"op": "delete"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantNames:  []string{"users", "health"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, cm := newHandler(t)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body)))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+
+			var names []string
+			for _, pc := range cm.GetConfig().PathMatcher.GetAllConfigs() {
+				names = append(names, pc.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("loaded configs = %v, want %v", names, tt.wantNames)
+			}
+			if tt.wantOK == nil {
+				return
+			}
+
+			var resp BatchResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			var ok []bool
+			for _, result := range resp.Results {
+				ok = append(ok, result.OK)
+			}
+			if !reflect.DeepEqual(ok, tt.wantOK) {
+				t.Errorf("results ok = %v, want %v: %+v", ok, tt.wantOK, resp.Results)
+			}
+			if wantApplied := tt.wantStatus == http.StatusOK && !resp.DryRun; resp.Applied != wantApplied {
+				t.Errorf("applied = %v, want %v", resp.Applied, wantApplied)
+			}
+		})
+	}
+}
+
+func TestConfigBatchIsAtomic(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher()})
+	handler := NewConfigurationHandler(cm)
+
+	const stubs = 30
+	ops := make([]BatchOperation, stubs)
+	for i := range ops {
+		ops[i] = BatchOperation{Op: OpCreate, Config: &config.PathConfig{Name: fmt.Sprintf("stub-%d", i), Pattern: fmt.Sprintf("^/stub/%d$", i)}}
+	}
+	body, err := json.Marshal(BatchRequest{Operations: ops})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if n := len(cm.GetConfig().PathMatcher.GetAllConfigs()); n != 0 && n != stubs {
+				t.Errorf("observed %d of %d configs", n, stubs)
+				return
+			}
+		}
+	}()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/config/batch", strings.NewReader(string(body))))
+	close(done)
+	wg.Wait()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, ok := cm.GetConfig().PathMatcher.Match("/stub/29", http.MethodGet); !ok {
+		t.Error("last stub not matched")
+	}
+}
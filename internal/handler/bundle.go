@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+	"echo-server/internal/namespace"
+	"echo-server/pkg/logger"
+
+	"github.com/oasdiff/yaml"
+)
+
+// Bundle is the complete configuration of a server, to move mocks between
+// environments. Server is informational on import: it is neither applied nor
+// saved, the server settings come from the configuration file.
+type Bundle struct {
+	Server   *config.ServerConfig `json:"server,omitempty"`
+	Paths    []config.PathConfig  `json:"paths"`
+	Counters *counter.Snapshot    `json:"counters,omitempty"`
+}
+
+const (
+	ImportModeReplace = "replace"
+	ImportModeMerge   = "merge"
+)
+
+// BundleImportResult describes what an import changed, or would change with
+// ?dryRun. Configs are listed by name, or pattern when they have none.
+type BundleImportResult struct {
+	Mode     string   `json:"mode"`
+	DryRun   bool     `json:"dryRun,omitempty"`
+	Paths    int      `json:"paths"`
+	Added    []string `json:"added"`
+	Replaced []string `json:"replaced"`
+	Removed  []string `json:"removed"`
+	Counters bool     `json:"counters"`
+	// ServerIgnored is set when the bundle had a server section, which is
+	// ignored
+	ServerIgnored bool `json:"serverIgnored,omitempty"`
+}
+
+// wantsYAML reports whether the client asked for YAML with ?format=yaml or
+// the Accept header
+func wantsYAML(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "yaml" || format == "yml"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "yaml")
+}
+
+// handleExport returns the server config and all path configs as a bundle,
+// with ?counters the current counter values too. Admin credentials are left
+// out.
+func (h *ConfigurationHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	cfg := h.serverConfig(r)
+	server := *cfg
+	if server.Admin != nil {
+		admin := *server.Admin
+		admin.Token, admin.Password = "", ""
+		server.Admin = &admin
+	}
+
+	bundle := Bundle{Server: &server, Paths: cfg.PathMatcher.GetAllConfigs()}
+	if r.URL.Query().Has("counters") {
+		snapshot := namespace.Counter(r.Context()).Snapshot()
+		bundle.Counters = &snapshot
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		logger.Error("Failed to encode config bundle: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	contentType, ext := "application/json", "json"
+	if wantsYAML(r) {
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			logger.Error("Failed to convert config bundle to YAML: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		contentType, ext = "application/yaml", "yaml"
+	} else {
+		data = append(data, '\n')
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="echo-server-bundle.`+ext+`"`)
+	w.Write(data)
+}
+
+// decodeBundle strictly decodes a JSON or YAML bundle
+func decodeBundle(data []byte, contentType string) (Bundle, error) {
+	var bundle Bundle
+	trimmed := bytes.TrimSpace(data)
+	if strings.Contains(contentType, "yaml") || !bytes.HasPrefix(trimmed, []byte("{")) {
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return bundle, fmt.Errorf("parsing YAML: %w", err)
+		}
+		data = converted
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&bundle); err != nil {
+		return bundle, err
+	}
+	return bundle, nil
+}
+
+// mergeConfigs replaces configs in current with the bundle configs of the
+// same name, keeping their position, and appends the others
+func mergeConfigs(current, incoming []config.PathConfig) []config.PathConfig {
+	merged := append([]config.PathConfig(nil), current...)
+	index := make(map[string]int)
+	for i, pc := range merged {
+		if pc.Name != "" {
+			index[pc.Name] = i
+		}
+	}
+	for _, pc := range incoming {
+		if i, ok := index[pc.Name]; ok && pc.Name != "" {
+			merged[i] = pc
+			continue
+		}
+		merged = append(merged, pc)
+	}
+	return merged
+}
+
+// diffConfigs lists the configs of next that are new or replace one in
+// current, and the configs of current that are gone
+func diffConfigs(current, incoming, next []config.PathConfig) (added, replaced, removed []string) {
+	added, replaced, removed = []string{}, []string{}, []string{}
+	before := make(map[string]bool)
+	for _, pc := range current {
+		before[pc.CounterKey()] = true
+	}
+	after := make(map[string]bool)
+	for _, pc := range next {
+		after[pc.CounterKey()] = true
+	}
+	for _, pc := range incoming {
+		if before[pc.CounterKey()] {
+			replaced = append(replaced, pc.CounterKey())
+		} else {
+			added = append(added, pc.CounterKey())
+		}
+	}
+	for _, pc := range current {
+		if !after[pc.CounterKey()] {
+			removed = append(removed, pc.CounterKey())
+		}
+	}
+	return added, replaced, removed
+}
+
+// handleImportBundle loads a bundle written by handleExport. With
+// ?mode=replace (default) its path configs replace all loaded ones, with
+// ?mode=merge they replace configs of the same name and are appended
+// otherwise. Everything is validated before anything changes and the path
+// configs are swapped at once, so a failed import leaves the server as it
+// was. With ?dryRun only the result is returned.
+func (h *ConfigurationHandler) handleImportBundle(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = ImportModeReplace
+	}
+	if mode != ImportModeReplace && mode != ImportModeMerge {
+		http.Error(w, "mode must be replace or merge", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	bundle, err := decodeBundle(data, r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, "Invalid bundle: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := config.ValidationReport{Configs: len(bundle.Paths), Errors: []config.ValidationError{}}
+	for i := range bundle.Paths {
+		for _, verr := range config.ValidatePathConfig(&bundle.Paths[i]) {
+			verr.Field = strings.TrimSuffix(fmt.Sprintf("paths[%d].%s", i, verr.Field), ".")
+			report.Errors = append(report.Errors, verr)
+		}
+	}
+	if bundle.Counters != nil {
+		if err := bundle.Counters.Validate(); err != nil {
+			report.Errors = append(report.Errors, config.ValidationError{Field: "counters", Message: err.Error()})
+		}
+	}
+	if len(report.Errors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logger.Error("Failed to encode validation report: %v", err)
+		}
+		return
+	}
+
+	result := BundleImportResult{
+		Mode:          mode,
+		DryRun:        r.URL.Query().Has("dryRun"),
+		Counters:      bundle.Counters != nil,
+		ServerIgnored: bundle.Server != nil,
+	}
+	plan := func(current []config.PathConfig) []config.PathConfig {
+		next := bundle.Paths
+		if mode == ImportModeMerge {
+			next = mergeConfigs(current, bundle.Paths)
+		}
+		if next == nil {
+			next = []config.PathConfig{}
+		}
+		result.Paths = len(next)
+		result.Added, result.Replaced, result.Removed = diffConfigs(current, bundle.Paths, next)
+		return next
+	}
+
+	if result.DryRun {
+		plan(h.serverConfig(r).PathMatcher.GetAllConfigs())
+	} else {
+		// Merging in the update keeps changes made concurrently
+		_, err := h.updateConfigs(r, func(current []config.PathConfig) ([]config.PathConfig, error) {
+			return plan(current), nil
+		})
+		if err != nil {
+			logger.Error("Failed to import config bundle: %v", err)
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if bundle.Counters != nil {
+			if err := namespace.Counter(r.Context()).Restore(*bundle.Counters); err != nil {
+				logger.Error("Failed to restore counters from bundle: %v", err)
+			}
+		}
+		if bundle.Server != nil {
+			logger.Warn("Ignored the server section of the imported bundle, copy it into the server configuration file to use it")
+		}
+		logger.Info("Imported config bundle (%s): %d added, %d replaced, %d removed", mode, len(result.Added), len(result.Replaced), len(result.Removed))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logger.Error("Failed to encode import result: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+)
+
+func TestConfigExport(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{
+		Port:        8080,
+		PathMatcher: config.NewPathMatcher(),
+		Admin:       &config.AdminConfig{Prefix: "/_admin", Token: "secret"},
+	})
+	if err := cm.UpdatePathConfig(config.PathConfig{Name: "users", Pattern: "^/users$", Response: config.ResponseConfig{StatusCode: 200, Body: "[]"}}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewConfigurationHandler(cm)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/config/export?counters", nil))
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var bundle Bundle
+	if err := json.NewDecoder(rr.Body).Decode(&bundle); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Server == nil || bundle.Server.Port != 8080 || bundle.Server.Admin.Prefix != "/_admin" {
+		t.Errorf("server = %+v", bundle.Server)
+	}
+	if bundle.Server.Admin.Token != "" || cm.GetConfig().Admin.Token != "secret" {
+		t.Error("admin token must be left out of the export only")
+	}
+	if len(bundle.Paths) != 1 || bundle.Paths[0].Name != "users" || bundle.Counters == nil {
+		t.Errorf("bundle = %+v", bundle)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/config/export", nil)
+	req.Header.Set("Accept", "application/yaml")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if ct := rr.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "pattern: ^/users$") || strings.Contains(body, "counters:") {
+		t.Errorf("unexpected YAML export:\n%s", body)
+	}
+}
+
+func TestConfigImportBundle(t *testing.T) {
+	newHandler := func(t *testing.T) (*ConfigurationHandler, *config.ConfigManager) {
+		cm := config.NewConfigManager()
+		cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher()})
+		for _, pc := range []config.PathConfig{
+			{Name: "users", Pattern: "^/users$"},
+			{Name: "health", Pattern: "^/health$"},
+		} {
+			if err := cm.UpdatePathConfig(pc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return NewConfigurationHandler(cm), cm
+	}
+	bundle := `{"server": {"port": 9999}, "paths": [
+		{"name": "users", "pattern": "^/users/v2$"},
+		{"name": "orders", "pattern": "^/orders$"}
+	]}`
+	yamlBundle := "paths:\n  - name: orders\n    pattern: ^/orders$\n    response:\n      statusCode: 202\n      delay: 10ms\n"
+
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantStatus int
+		wantResult BundleImportResult
+		wantNames  []string
+	}{
+		{
+			name:       "replace",
+			target:     "/config/import",
+			body:       bundle,
+			wantStatus: http.StatusOK,
+			wantResult: BundleImportResult{Mode: "replace", Paths: 2, Added: []string{"orders"}, Replaced: []string{"users"}, Removed: []string{"health"}, ServerIgnored: true},
+			wantNames:  []string{"users", "orders"},
+		},
+		{
+			name:       "merge",
+			target:     "/config/import?mode=merge",
+			body:       bundle,
+			wantStatus: http.StatusOK,
+			wantResult: BundleImportResult{Mode: "merge", Paths: 3, Added: []string{"orders"}, Replaced: []string{"users"}, Removed: []string{}, ServerIgnored: true},
+			wantNames:  []string{"users", "health", "orders"},
+		},
+		{
+			name:       "dry run",
+			target:     "/config/import?dryRun",
+			body:       bundle,
+			wantStatus: http.StatusOK,
+			wantResult: BundleImportResult{Mode: "replace", DryRun: true, Paths: 2, Added: []string{"orders"}, Replaced: []string{"users"}, Removed: []string{"health"}, ServerIgnored: true},
+			wantNames:  []string{"users", "health"},
+		},
+		{
+			name:       "yaml",
+			target:     "/config/import?mode=merge",
+			body:       yamlBundle,
+			wantStatus: http.StatusOK,
+			wantResult: BundleImportResult{Mode: "merge", Paths: 3, Added: []string{"orders"}, Replaced: []string{}, Removed: []string{}},
+			wantNames:  []string{"users", "health", "orders"},
+		},
+		{
+			name:       "invalid config changes nothing",
+			target:     "/config/import",
+			body:       `{"paths": [{"name": "ok", "pattern": "^/ok$"}, {"name": "broken", "pattern": "("}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantNames:  []string{"users", "health"},
+		},
+		{
+			name:       "unknown field",
+			target:     "/config/import",
+			body:       `{"paths": [], "scenarios": {}}`,
+			wantStatus: http.StatusBadRequest,
+			wantNames:  []string{"users", "health"},
+		},
+		{
+			name:       "unknown mode",
+			target:     "/config/import?mode=append",
+			body:       bundle,
+			wantStatus: http.StatusBadRequest,
+			wantNames:  []string{"users", "health"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, cm := newHandler(t)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body)))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+
+			var names []string
+			for _, pc := range cm.GetConfig().PathMatcher.GetAllConfigs() {
+				names = append(names, pc.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("loaded configs = %v, want %v", names, tt.wantNames)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			var result BundleImportResult
+			if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tt.wantResult) {
+				t.Errorf("result = %+v\nwant %+v", result, tt.wantResult)
+			}
+		})
+	}
+
+	t.Run("counters", func(t *testing.T) {
+		c := counter.GetGlobalCounter()
+		c.Reset()
+		defer c.Reset()
+
+		handler, _ := newHandler(t)
+		rr := httptest.NewRecorder()
+		body := `{"paths": [], "counters": {"global": 5, "paths": {"/users": 5}, "statuses": {"200": 5}}}`
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/config/import", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+		}
+		if c.GetCount() != 5 || c.GetPathCount("/users") != 5 {
+			t.Errorf("counters not restored: global %d, /users %d", c.GetCount(), c.GetPathCount("/users"))
+		}
+	})
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
 	"echo-server/internal/config"
+	"echo-server/internal/namespace"
 	"echo-server/pkg/logger"
 
 	"github.com/samber/lo"
@@ -21,10 +24,38 @@ func NewConfigurationHandler(cm *config.ConfigManager) *ConfigurationHandler {
 	}
 }
 
+// serverConfig returns the configuration the request applies to, the one of
+// its namespace if it was sent to one
+func (h *ConfigurationHandler) serverConfig(r *http.Request) *config.ServerConfig {
+	return namespace.Config(r.Context(), h.configManager.GetConfig())
+}
+
 func (h *ConfigurationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	segments := strings.Split(r.URL.Path+"/", "/")
 
 	switch {
+	case segments[2] == "explain" && (r.Method == http.MethodGet || r.Method == http.MethodPost):
+		h.handleExplain(w, r)
+	case r.Method == http.MethodPost && segments[2] == "import" && segments[3] == "openapi":
+		h.handleImportOpenAPI(w, r)
+	case r.Method == http.MethodPost && segments[2] == "import" && segments[3] == "har":
+		h.handleImportHAR(w, r)
+	case r.Method == http.MethodPost && segments[2] == "import" && segments[3] == "":
+		h.handleImportBundle(w, r)
+	case r.Method == http.MethodGet && segments[2] == "examples":
+		h.handleExamples(w, r)
+	case r.Method == http.MethodGet && segments[2] == "export" && segments[3] == "postman":
+		h.handleExportPostman(w, r)
+	case r.Method == http.MethodGet && segments[2] == "export" && segments[3] == "":
+		h.handleExport(w, r)
+	case r.Method == http.MethodGet && segments[2] == "history":
+		h.handleHistory(w, r, segments[3])
+	case r.Method == http.MethodPost && segments[2] == "rollback":
+		h.handleRollback(w, r, segments[3])
+	case r.Method == http.MethodPost && segments[2] == "batch":
+		h.handleBatch(w, r)
+	case r.Method == http.MethodPost && segments[2] == "validate":
+		h.handleValidate(w, r)
 	case r.Method == http.MethodGet:
 		h.handleGet(w, r, segments[2])
 	case r.Method == http.MethodPost:
@@ -38,10 +69,21 @@ func (h *ConfigurationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// PathConfigStatus is a path config as listed by GET /config, with the
+// number of requests left for configs with maxUses
+type PathConfigStatus struct {
+	config.PathConfig
+	RemainingUses *int `json:"remainingUses,omitempty"`
+}
+
 func (h *ConfigurationHandler) handleGet(w http.ResponseWriter, r *http.Request, name string) {
-	cfg := h.configManager.GetConfig()
-	configs := lo.Filter(cfg.PathMatcher.GetAllConfigs(), func(item config.PathConfig, _ int) bool {
-		return name == "" || item.Name == name
+	cfg := h.serverConfig(r)
+	configs := lo.FilterMap(cfg.PathMatcher.GetAllConfigs(), func(item config.PathConfig, _ int) (PathConfigStatus, bool) {
+		status := PathConfigStatus{PathConfig: item}
+		if remaining, ok := item.RemainingUses(); ok {
+			status.RemainingUses = &remaining
+		}
+		return status, name == "" || item.Name == name
 	})
 
 	w.Header().Set("Content-Type", "application/json")
@@ -53,13 +95,20 @@ func (h *ConfigurationHandler) handleGet(w http.ResponseWriter, r *http.Request,
 }
 
 func (h *ConfigurationHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("dryRun") {
+		h.handleValidate(w, r)
+		return
+	}
+
 	var pathCfg config.PathConfig
 	if err := json.NewDecoder(r.Body).Decode(&pathCfg); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.configManager.UpdatePathConfig(pathCfg); err != nil {
+	if _, err := h.updateConfigs(r, func(configs []config.PathConfig) ([]config.PathConfig, error) {
+		return append(configs, pathCfg), nil
+	}); err != nil {
 		logger.Error("Failed to update path config: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -76,7 +125,9 @@ func (h *ConfigurationHandler) handlePut(w http.ResponseWriter, r *http.Request,
 	}
 
 	pathCfg.Pattern = name
-	if err := h.configManager.UpdatePathConfig(pathCfg); err != nil {
+	if _, err := h.updateConfigs(r, func(configs []config.PathConfig) ([]config.PathConfig, error) {
+		return append(configs, pathCfg), nil
+	}); err != nil {
 		logger.Error("Failed to update path config: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -86,9 +137,52 @@ func (h *ConfigurationHandler) handlePut(w http.ResponseWriter, r *http.Request,
 }
 
 func (h *ConfigurationHandler) handleDelete(w http.ResponseWriter, r *http.Request, name string) {
-	if deleted := h.configManager.GetConfig().PathMatcher.DeleteByName(name); !deleted {
+	_, err := h.updateConfigs(r, func(configs []config.PathConfig) ([]config.PathConfig, error) {
+		for i := range configs {
+			if configs[i].Name == name {
+				return append(configs[:i], configs[i+1:]...), nil
+			}
+		}
+		return nil, errConfigNotFound
+	})
+	if errors.Is(err, errConfigNotFound) {
+		logger.Warn("No path pattern found with name: %s", name)
 		http.Error(w, "Configuration not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		logger.Error("Failed to delete path config: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// handleValidate checks one path config or an array of them as if they were
+// added now, without changing anything. It responds 200 when they are valid
+// and 422 with the problems found otherwise.
+func (h *ConfigurationHandler) handleValidate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var report config.ValidationReport
+	configs, err := config.DecodePathConfigsStrict(body)
+	if err != nil {
+		report = config.ValidationReport{Errors: []config.ValidationError{{Message: err.Error()}}}
+	} else {
+		report = config.ValidateAdditions(h.serverConfig(r).PathMatcher.GetAllConfigs(), configs)
+	}
+
+	status := http.StatusOK
+	if !report.Valid {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logger.Error("Failed to encode validation report: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -73,3 +74,89 @@ func TestConfigHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigValidate(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher()})
+	if err := cm.UpdatePathConfig(config.PathConfig{Name: "all-users", Pattern: "^/users/.*"}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewConfigurationHandler(cm)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"valid config", "/config/validate", `{"name": "orders", "pattern": "^/orders$"}`, http.StatusOK, `"valid":true`},
+		{"unknown field", "/config/validate", `{"pattern": "^/orders$", "statuscode": 200}`, http.StatusUnprocessableEntity, `unknown field`},
+		{"shadowed by loaded config", "/config/validate", `[{"name": "user", "pattern": "^/users/1$"}]`, http.StatusUnprocessableEntity, `loaded config \"all-users\"`},
+		{"dry run post", "/config?dryRun", `{"pattern": "^/a$", "errorEvery": 2}`, http.StatusUnprocessableEntity, `"field":"errorEvery"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if got := len(cm.GetConfig().PathMatcher.GetAllConfigs()); got != 1 {
+		t.Errorf("validation changed the loaded configs: %d configs, want 1", got)
+	}
+}
+
+func TestConfigTemporary(t *testing.T) {
+	cm := config.NewConfigManager()
+	configs := NewConfigurationHandler(cm)
+	echo := NewEchoHandler(cm.GetConfig())
+
+	for _, body := range []string{
+		`{"name": "payments", "pattern": "^/payments$", "response": {"statusCode": 200}}`,
+		`{"name": "payments-down", "pattern": "^/payments$", "maxUses": 2, "ttl": "1h", "response": {"statusCode": 503}}`,
+	} {
+		w := httptest.NewRecorder()
+		configs.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/config/paths", strings.NewReader(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("POST = %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	listed := func() []PathConfigStatus {
+		w := httptest.NewRecorder()
+		configs.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config", nil))
+		var list []PathConfigStatus
+		if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+
+	// The temporary config answers first although it was added last
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK} {
+		if i == 1 {
+			list := listed()
+			if len(list) != 2 || list[1].RemainingUses == nil || *list[1].RemainingUses != 1 || list[1].ExpiresAt.IsZero() {
+				t.Errorf("configs after one use = %+v", list)
+			}
+		}
+		w := httptest.NewRecorder()
+		echo.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/payments", nil))
+		if w.Code != want {
+			t.Errorf("request %d = %d, want %d", i+1, w.Code, want)
+		}
+	}
+
+	if list := listed(); len(list) != 1 || list[0].Name != "payments" || list[0].RemainingUses != nil {
+		t.Errorf("configs after the last use = %+v", list)
+	}
+}
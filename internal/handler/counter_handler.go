@@ -3,44 +3,106 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"regexp"
 	"strings"
 
 	"echo-server/internal/counter"
+	"echo-server/internal/namespace"
 	"echo-server/pkg/logger"
 )
 
 type CounterResponse struct {
-	GlobalCount uint64            `json:"globalCount"`
-	PathCounts  map[string]uint64 `json:"pathCounts,omitempty"`
+	Global   uint64            `json:"global"`
+	Paths    map[string]uint64 `json:"paths"`
+	Configs  map[string]uint64 `json:"configs,omitempty"`
+	Methods  map[string]uint64 `json:"methods,omitempty"`
+	Statuses map[string]uint64 `json:"statuses,omitempty"`
+	// Positions are how many entries of Responses each config served
+	Positions map[string]uint64 `json:"positions,omitempty"`
+	Rates     map[string]uint64 `json:"rates"`
 }
 
+// PathCounterResponse is returned for a single path counter
+type PathCounterResponse struct {
+	Path  string `json:"path"`
+	Count uint64 `json:"count"`
+}
+
+// CounterValue is the body of a PUT request setting a counter
+type CounterValue struct {
+	Count *uint64 `json:"count"`
+}
+
+// CounterHandler serves /counter and /counter/{path}, where {path} is the
+// request path a counter belongs to, e.g. /counter/api/test for /api/test
 func CounterHandler(w http.ResponseWriter, r *http.Request) {
-	c := counter.GetGlobalCounter()
+	c := namespace.Counter(r.Context())
+	path := strings.TrimPrefix(r.URL.Path, "/counter")
 
 	switch r.Method {
 	case http.MethodGet:
-		// Return counter values
-		response := CounterResponse{
-			GlobalCount: c.GetCount(),
-			PathCounts:  c.GetAllPathCounts(),
+		if path != "" && path != "/" {
+			writeCounterJSON(w, PathCounterResponse{Path: path, Count: c.GetPathCount(path)})
+			return
 		}
+		writeCounterJSON(w, CounterResponse{
+			Global:    c.GetCount(),
+			Paths:     c.GetAllPathCounts(),
+			Configs:   c.GetAllConfigCounts(),
+			Methods:   c.GetAllMethodCounts(),
+			Statuses:  c.GetAllStatusCounts(),
+			Positions: c.GetAllPositions(),
+			Rates:     c.Rates(),
+		})
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			logger.Error("Failed to encode counter response: %v", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	case http.MethodDelete:
+		if path != "" && path != "/" {
+			c.ResetPath(path)
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
-	case http.MethodDelete:
-		// Handle reset operations
-		path := r.URL.Query().Get("path")
-		if path != "" {
-			c.ResetPath(strings.TrimSpace(path))
-			logger.Info("Reset counter for path: %s", path)
-		} else {
+		query := r.URL.Query()
+		switch {
+		case query.Get("path") != "":
+			c.ResetPath(strings.TrimSpace(query.Get("path")))
+		case query.Get("prefix") != "":
+			c.ResetPathPrefix(query.Get("prefix"))
+		case query.Get("regex") != "":
+			re, err := regexp.Compile(query.Get("regex"))
+			if err != nil {
+				http.Error(w, "Invalid regex: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			c.ResetPathRegex(re)
+		case query.Get("config") != "":
+			c.ResetConfig(strings.TrimSpace(query.Get("config")))
+		case query.Get("position") != "":
+			c.ResetPosition(strings.TrimSpace(query.Get("position")))
+		default:
 			c.Reset()
-			logger.Info("Reset all counters")
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodPut:
+		var value CounterValue
+		if err := json.NewDecoder(r.Body).Decode(&value); err != nil || value.Count == nil {
+			http.Error(w, `Invalid request body, expected {"count": <number>}`, http.StatusBadRequest)
+			return
+		}
+
+		query := r.URL.Query()
+		switch {
+		case path != "" && path != "/":
+			c.SetPath(path, *value.Count)
+		case query.Get("config") != "":
+			c.SetConfig(query.Get("config"), *value.Count)
+		case query.Get("method") != "":
+			c.SetMethod(strings.ToUpper(query.Get("method")), *value.Count)
+		case query.Get("position") != "":
+			c.SetPosition(query.Get("position"), *value.Count)
+		default:
+			c.Set(*value.Count)
 		}
 		w.WriteHeader(http.StatusNoContent)
 
@@ -48,3 +110,57 @@ func CounterHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+func writeCounterJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode counter response: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// CounterExportHandler serves GET /counter/export, a snapshot of all counters
+// that can be loaded into another instance with CounterImportHandler
+func CounterExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(namespace.Counter(r.Context()).Snapshot()); err != nil {
+		logger.Error("Failed to encode counter snapshot: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// CounterImportHandler serves POST /counter/import, replacing all counters
+// with a snapshot produced by CounterExportHandler
+func CounterImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var snapshot counter.Snapshot
+	if err := json.NewDecoder(r.Body).Decode(&snapshot); err != nil {
+		http.Error(w, "Invalid snapshot: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := namespace.Counter(r.Context()).Restore(snapshot); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CounterSnapshotHandler serves /counter-snapshot, an alias for both: GET
+// exports and PUT or POST imports. Unlike /counter/export and /counter/import
+// it cannot collide with the counter of a mocked path.
+func CounterSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		CounterExportHandler(w, r)
+		return
+	}
+	CounterImportHandler(w, r)
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"echo-server/internal/counter"
+)
+
+func TestCounterHandler(t *testing.T) {
+	c := counter.GetGlobalCounter()
+	c.Reset()
+	t.Cleanup(c.Reset)
+
+	tests := []struct {
+		name       string
+		setup      func()
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		check      func(t *testing.T, body string)
+	}{
+		{
+			name:       "get all uses documented shape",
+			setup:      func() { c.Increment(); c.IncrementPath("/api/test") },
+			method:     "GET",
+			target:     "/counter",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body string) {
+				var resp CounterResponse
+				if err := json.Unmarshal([]byte(body), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Global != 1 || resp.Paths["/api/test"] != 1 {
+					t.Errorf("response = %s", body)
+				}
+			},
+		},
+		{
+			name:       "get single path",
+			setup:      func() { c.SetPath("/api/test", 4) },
+			method:     "GET",
+			target:     "/counter/api/test",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body string) {
+				if !strings.Contains(body, `"count":4`) {
+					t.Errorf("response = %s", body)
+				}
+			},
+		},
+		{
+			name:       "reset path",
+			setup:      func() { c.SetPath("/api/test", 4) },
+			method:     "DELETE",
+			target:     "/counter/api/test",
+			wantStatus: http.StatusNoContent,
+			check:      wantPathCount(c, "/api/test", 0),
+		},
+		{
+			name:       "reset by prefix",
+			setup:      func() { c.SetPath("/api/a", 1); c.SetPath("/keep", 1) },
+			method:     "DELETE",
+			target:     "/counter?prefix=/api/",
+			wantStatus: http.StatusNoContent,
+			check: func(t *testing.T, body string) {
+				wantPathCount(c, "/api/a", 0)(t, body)
+				wantPathCount(c, "/keep", 1)(t, body)
+			},
+		},
+		{
+			name:       "reset by regex",
+			setup:      func() { c.SetPath("/users/42", 3) },
+			method:     "DELETE",
+			target:     "/counter?regex=" + url.QueryEscape(`^/users/\d+$`),
+			wantStatus: http.StatusNoContent,
+			check:      wantPathCount(c, "/users/42", 0),
+		},
+		{
+			name:       "invalid regex",
+			method:     "DELETE",
+			target:     "/counter?regex=" + url.QueryEscape("("),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "reset by config",
+			setup:      func() { c.SetConfig("users", 3) },
+			method:     "DELETE",
+			target:     "/counter?config=users",
+			wantStatus: http.StatusNoContent,
+			check: func(t *testing.T, _ string) {
+				if got := c.GetConfigCount("users"); got != 0 {
+					t.Errorf("config count = %d, want 0", got)
+				}
+			},
+		},
+		{
+			name:       "set path",
+			method:     "PUT",
+			target:     "/counter/api/test",
+			body:       `{"count": 9}`,
+			wantStatus: http.StatusNoContent,
+			check:      wantPathCount(c, "/api/test", 9),
+		},
+		{
+			name:       "set config",
+			method:     "PUT",
+			target:     "/counter?config=users",
+			body:       `{"count": 2}`,
+			wantStatus: http.StatusNoContent,
+			check: func(t *testing.T, _ string) {
+				if got := c.GetConfigCount("users"); got != 2 {
+					t.Errorf("config count = %d, want 2", got)
+				}
+			},
+		},
+		{
+			name:       "set global",
+			method:     "PUT",
+			target:     "/counter",
+			body:       `{"count": 100}`,
+			wantStatus: http.StatusNoContent,
+			check: func(t *testing.T, _ string) {
+				if got := c.GetCount(); got != 100 {
+					t.Errorf("global count = %d, want 100", got)
+				}
+			},
+		},
+		{
+			name:       "set without count",
+			method:     "PUT",
+			target:     "/counter/api/test",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "method not allowed",
+			method:     "PATCH",
+			target:     "/counter",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			w := httptest.NewRecorder()
+			CounterHandler(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func wantPathCount(c *counter.Counter, path string, want uint64) func(*testing.T, string) {
+	return func(t *testing.T, _ string) {
+		t.Helper()
+		if got := c.GetPathCount(path); got != want {
+			t.Errorf("count for %s = %d, want %d", path, got, want)
+		}
+	}
+}
+
+func TestCounterExportImport(t *testing.T) {
+	c := counter.GetGlobalCounter()
+	c.Reset()
+	t.Cleanup(c.Reset)
+
+	c.Increment()
+	c.IncrementPath("/exported")
+	c.IncrementConfig("exported")
+
+	w := httptest.NewRecorder()
+	CounterExportHandler(w, httptest.NewRequest("GET", "/counter/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export status = %d, want %d", w.Code, http.StatusOK)
+	}
+	exported := w.Body.String()
+
+	c.Reset()
+	c.IncrementPath("/other")
+
+	w = httptest.NewRecorder()
+	CounterImportHandler(w, httptest.NewRequest("POST", "/counter/import", strings.NewReader(exported)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("import status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	if c.GetCount() != 1 || c.GetPathCount("/exported") != 1 || c.GetConfigCount("exported") != 1 {
+		t.Errorf("imported counts = %d/%d/%d, want 1/1/1", c.GetCount(), c.GetPathCount("/exported"), c.GetConfigCount("exported"))
+	}
+	if c.GetPathCount("/other") != 0 {
+		t.Error("import should replace existing counters")
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{"invalid json", CounterImportHandler, "POST", "{", http.StatusBadRequest},
+		{"invalid status", CounterImportHandler, "POST", `{"statuses":{"x":1}}`, http.StatusBadRequest},
+		{"import wrong method", CounterImportHandler, "GET", "", http.StatusMethodNotAllowed},
+		{"export wrong method", CounterExportHandler, "POST", "", http.StatusMethodNotAllowed},
+		{"snapshot alias exports", CounterSnapshotHandler, "GET", "", http.StatusOK},
+		{"snapshot alias imports", CounterSnapshotHandler, "PUT", exported, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest(tt.method, "/counter", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
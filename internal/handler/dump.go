@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/config"
+	"echo-server/internal/journal"
+	"echo-server/internal/model"
+	"echo-server/pkg/logger"
+)
+
+const redactedValue = "[REDACTED]"
+
+// routeLogger applies the per path logging config: it returns the logger to
+// use for the rest of the request and dumps the request when configured
+func routeLogger(log *logger.Logger, lc *config.LoggingConfig, data *model.RequestData) *logger.Logger {
+	if lc.Level != "" {
+		// Validated when the path config was added
+		level, _ := logger.ParseLevel(lc.Level)
+		log = log.WithLevel(level)
+	}
+
+	fields := []any{
+		"method", data.Method,
+		"path", data.Path,
+		"query", data.QueryParams.Encode(),
+		"headers", redactHeaders(data.Headers, lc.RedactHeaders),
+	}
+	// Headers are dumped at debug level, an explicitly requested body dump
+	// at the route's level so it shows up whenever the route logs
+	level := logger.DEBUG
+	if lc.LogRequestBody {
+		level = dumpLevel(lc)
+		fields = append(fields,
+			"size", len(data.Body),
+			"body", truncateBody([]byte(data.Body), len(data.Body), lc.BodyLimit()),
+		)
+	}
+	log.Log(level, "Request dump", fields...)
+	return log
+}
+
+// dumpLevel is the level body dumps are logged at: the route's level, info
+// when the route has none
+func dumpLevel(lc *config.LoggingConfig) logger.Level {
+	if lc.Level == "" {
+		return logger.INFO
+	}
+	level, _ := logger.ParseLevel(lc.Level)
+	return level
+}
+
+// redactHeaders returns a copy of headers with the listed headers masked
+func redactHeaders(headers map[string][]string, redact []string) map[string][]string {
+	result := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		result[key] = values
+		for _, name := range redact {
+			if strings.EqualFold(key, name) {
+				result[key] = []string{redactedValue}
+				break
+			}
+		}
+	}
+	return result
+}
+
+// truncateBody returns body cut to limit bytes, without splitting a UTF-8
+// character, and how many bytes of total were left out
+func truncateBody(body []byte, total, limit int) string {
+	if total <= limit && len(body) <= limit {
+		return string(body)
+	}
+	body = journal.Cut(body, limit)
+	return fmt.Sprintf("%s...(%d bytes truncated)", body, total-len(body))
+}
+
+// captureWriter keeps the first bytes of a response so they can be logged
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	limit       int
+	size        int
+	buf         bytes.Buffer
+}
+
+func newCaptureWriter(w http.ResponseWriter, limit int) *captureWriter {
+	return &captureWriter{ResponseWriter: w, status: http.StatusOK, limit: limit}
+}
+
+// WriteHeader records the status of the response. Like http.ResponseWriter
+// it ignores calls after the first, except for informational responses.
+func (cw *captureWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.status = code
+	cw.wroteHeader = true
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	cw.wroteHeader = true
+	if remaining := cw.limit - cw.buf.Len(); remaining > 0 {
+		cw.buf.Write(b[:min(remaining, len(b))])
+	}
+	cw.size += len(b)
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *captureWriter) dump(log *logger.Logger, lc *config.LoggingConfig) {
+	log.Log(dumpLevel(lc), "Response dump",
+		"status", cw.status,
+		"headers", redactHeaders(cw.Header(), lc.RedactHeaders),
+		"size", cw.size,
+		"body", truncateBody(cw.buf.Bytes(), cw.size, cw.limit),
+	)
+}
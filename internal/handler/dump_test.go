@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/middleware"
+	"echo-server/pkg/logger"
+)
+
+// captureLogs sends the default logger's JSON output to a buffer for the
+// duration of the test
+func captureLogs(t *testing.T, level logger.Level) *bytes.Buffer {
+	t.Helper()
+
+	previous := logger.GetLevel()
+	var buf bytes.Buffer
+	if err := logger.SetFormat(logger.FormatJSON, &buf); err != nil {
+		t.Fatal(err)
+	}
+	logger.SetLevel(level)
+	t.Cleanup(func() {
+		logger.SetFormat(logger.FormatText, os.Stdout)
+		logger.SetLevel(previous)
+	})
+	return &buf
+}
+
+func findLogEntry(t *testing.T, logs *bytes.Buffer, msg string) map[string]interface{} {
+	t.Helper()
+
+	for _, line := range strings.Split(logs.String(), "\n") {
+		var entry map[string]interface{}
+		if json.Unmarshal([]byte(line), &entry) == nil && entry["msg"] == msg {
+			return entry
+		}
+	}
+	return nil
+}
+
+func TestTruncateBody(t *testing.T) {
+	tests := []struct {
+		body  string
+		total int
+		limit int
+		want  string
+	}{
+		{body: "short", total: 5, limit: 10, want: "short"},
+		{body: "0123456789", total: 10, limit: 4, want: "0123...(6 bytes truncated)"},
+		{body: "aé€", total: 6, limit: 2, want: "a...(5 bytes truncated)"},
+		{body: "aé€", total: 6, limit: 5, want: "aé...(3 bytes truncated)"},
+		// Captured responses only hold limit bytes
+		{body: "a\xe2\x82", total: 6, limit: 3, want: "a...(5 bytes truncated)"},
+	}
+	for _, tt := range tests {
+		if got := truncateBody([]byte(tt.body), tt.total, tt.limit); got != tt.want {
+			t.Errorf("truncateBody(%q, %d, %d) = %q, want %q", tt.body, tt.total, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestCaptureWriterWriteHeader(t *testing.T) {
+	rr := httptest.NewRecorder()
+	cw := newCaptureWriter(rr, 10)
+	cw.WriteHeader(http.StatusCreated)
+	cw.WriteHeader(http.StatusInternalServerError)
+	cw.Write([]byte("ok"))
+	if cw.status != http.StatusCreated || rr.Code != http.StatusCreated {
+		t.Errorf("status = %d, recorded %d, want %d", cw.status, rr.Code, http.StatusCreated)
+	}
+
+	// Informational responses come before the final status
+	cw = newCaptureWriter(httptest.NewRecorder(), 10)
+	cw.WriteHeader(http.StatusEarlyHints)
+	cw.WriteHeader(http.StatusAccepted)
+	if cw.status != http.StatusAccepted {
+		t.Errorf("status after 103 = %d, want %d", cw.status, http.StatusAccepted)
+	}
+
+	// Writing the body sends the header
+	rr = httptest.NewRecorder()
+	cw = newCaptureWriter(rr, 10)
+	cw.Write([]byte("ok"))
+	cw.WriteHeader(http.StatusTeapot)
+	if cw.status != http.StatusOK {
+		t.Errorf("status after Write = %d, want %d", cw.status, http.StatusOK)
+	}
+}
+
+func TestRouteLogging(t *testing.T) {
+	logs := captureLogs(t, logger.WARN)
+
+	cfg := &config.ServerConfig{
+		PathMatcher: config.NewPathMatcher(),
+	}
+	for _, pc := range []config.PathConfig{
+		{
+			Name:    "verbose",
+			Pattern: "^/verbose$",
+			Response: config.ResponseConfig{
+				Headers: map[string]string{"Set-Cookie": "session=secret"},
+				Body:    `{"result":"0123456789"}`,
+			},
+			Logging: &config.LoggingConfig{
+				Level:           "debug",
+				LogRequestBody:  true,
+				LogResponseBody: true,
+				RedactHeaders:   []string{"authorization", "set-cookie"},
+				MaxBodyBytes:    12,
+			},
+		},
+		{Name: "quiet", Pattern: "^/quiet$"},
+	} {
+		if err := cfg.PathMatcher.Add(&pc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := middleware.RequestLogging(NewEchoHandler(cfg))
+
+	req := httptest.NewRequest("POST", "/verbose", strings.NewReader(`{"card":"4111111111111111"}`))
+	req.Header.Set("Authorization", "Bearer token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	dump := findLogEntry(t, logs, "Request dump")
+	if dump == nil {
+		t.Fatalf("Expected request dump despite warn level, logs: %s", logs)
+	}
+	if dump["body"] != `{"card":"411...(15 bytes truncated)` {
+		t.Errorf("Request body = %v", dump["body"])
+	}
+	if auth := dump["headers"].(map[string]interface{})["Authorization"]; auth.([]interface{})[0] != redactedValue {
+		t.Errorf("Authorization header was not redacted: %v", auth)
+	}
+	if findLogEntry(t, logs, "Parsed response body as JSON: map[result:0123456789]") == nil {
+		t.Error("Expected debug lines for the verbose route")
+	}
+
+	response := findLogEntry(t, logs, "Response dump")
+	if response == nil {
+		t.Fatal("Expected response dump")
+	}
+	if response["body"] != `{"result":"0...(12 bytes truncated)` {
+		t.Errorf("Response body = %v", response["body"])
+	}
+	if cookie := response["headers"].(map[string]interface{})["Set-Cookie"]; cookie.([]interface{})[0] != redactedValue {
+		t.Errorf("Set-Cookie header was not redacted: %v", cookie)
+	}
+
+	logs.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/quiet", nil))
+	if logs.Len() != 0 {
+		t.Errorf("Expected no logs at warn level for other routes, got %s", logs)
+	}
+}
+
+func TestInvalidRouteLogLevel(t *testing.T) {
+	pm := config.NewPathMatcher()
+	err := pm.Add(&config.PathConfig{
+		Pattern: "^/bad$",
+		Logging: &config.LoggingConfig{Level: "verbose"},
+	})
+	if err == nil {
+		t.Error("Expected invalid log level to be rejected")
+	}
+}
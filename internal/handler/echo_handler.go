@@ -3,8 +3,6 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"sync"
@@ -13,8 +11,17 @@ import (
 
 	"echo-server/internal/config"
 	"echo-server/internal/counter"
+	"echo-server/internal/journal"
 	"echo-server/internal/model"
+	"echo-server/internal/namespace"
+	"echo-server/internal/openapi"
+	"echo-server/internal/tracing"
 	"echo-server/pkg/logger"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type EchoHandler struct {
@@ -28,10 +35,10 @@ func NewEchoHandler(cfg *config.ServerConfig) *EchoHandler {
 	}
 }
 
-func (h *EchoHandler) processResponseBody(body string, data *model.RequestData) (interface{}, error) {
+func (h *EchoHandler) processResponseBody(log *logger.Logger, body string, data *model.RequestData) (interface{}, error) {
 	// If body starts with "template:", process it as a Go template
 	if strings.HasPrefix(body, "template:") {
-		logger.Debug("Processing response body as template")
+		log.Debug("Processing response body as template")
 		tmpl, err := template.New("response").Parse(strings.TrimPrefix(body, "template:"))
 		if err != nil {
 			return nil, err
@@ -48,21 +55,21 @@ func (h *EchoHandler) processResponseBody(body string, data *model.RequestData)
 	var result interface{}
 	if err := json.Unmarshal([]byte(body), &result); err != nil {
 		// If not valid JSON, return as string
-		logger.Debug("Response body is not valid JSON, returning as string")
+		log.Debug("Response body is not valid JSON, returning as string")
 		return body, nil
 	}
-	logger.Debug("Parsed response body as JSON: %v", result)
+	log.Debug("Parsed response body as JSON: %v", result)
 	return result, nil
 }
 
-func (h *EchoHandler) shouldReturnError(pathConfig *config.PathConfig, count uint64) bool {
+func (h *EchoHandler) shouldReturnError(log *logger.Logger, pathConfig *config.PathConfig, count uint64) bool {
 	if pathConfig == nil || pathConfig.ErrorResponse == nil {
 		return false
 	}
 
 	// Check ErrorEvery condition
-	if pathConfig.ErrorEvery > 0 && count > 0 && count%uint64(pathConfig.ErrorEvery) == 0 {
-		logger.Info("Triggering error response for path: %s (count: %d, errorEvery: %d)",
+	if errorDue(pathConfig, count) {
+		log.Info("Triggering error response for path: %s (count: %d, errorEvery: %d)",
 			pathConfig.Pattern, count, pathConfig.ErrorEvery)
 		return true
 	}
@@ -70,62 +77,152 @@ func (h *EchoHandler) shouldReturnError(pathConfig *config.PathConfig, count uin
 	return false
 }
 
+// errorDue reports whether ErrorEvery triggers at the given counter value
+func errorDue(pathConfig *config.PathConfig, count uint64) bool {
+	return pathConfig.ErrorResponse != nil && pathConfig.ErrorEvery > 0 && count > 0 && count%uint64(pathConfig.ErrorEvery) == 0
+}
+
+// errorEveryCount returns the current value of the counter ErrorEvery is
+// keyed on for this config
+func errorEveryCount(c *counter.Counter, pathConfig *config.PathConfig, r *http.Request) uint64 {
+	switch pathConfig.ErrorEveryCounter {
+	case config.CounterConfig:
+		return c.GetConfigCount(pathConfig.CounterKey())
+	case config.CounterMethod:
+		return c.GetMethodCount(r.Method)
+	case config.CounterGlobal:
+		return c.GetCount()
+	default:
+		return c.GetPathCount(r.URL.Path)
+	}
+}
+
 func (h *EchoHandler) handleResponse(w http.ResponseWriter, r *http.Request, data *model.RequestData) {
+	log := logger.FromContext(r.Context())
+	meta := model.RequestMetaFromContext(r.Context())
+
 	// Get counter instance
-	c := counter.GetGlobalCounter()
+	c := namespace.Counter(r.Context())
 
-	// Look up path configuration
-	pathConfig, matched := h.config.PathMatcher.Match(r.URL.Path, r.Method)
+	// Look up path configuration. Only requests answered as configured, not
+	// rejected by request validation, use up a config's maxUses and advance
+	// its responses. When a concurrent request took the last use the request
+	// is matched again.
+	_, matchSpan := tracing.Tracer().Start(r.Context(), "match")
+	var (
+		pathConfig *config.PathConfig
+		matched    bool
+		vc         *config.RequestValidationConfig
+		report     *openapi.ValidationReport
+		checkErr   error
+	)
+	for {
+		pathConfig, matched = h.config.PathMatcher.Match(r.URL.Path, r.Method)
+		vc, report, checkErr = h.requestValidation(pathConfig, matched), nil, nil
+		if vc != nil {
+			report, checkErr = checkRequest(r, vc, data)
+		}
+		if !matched || report != nil || checkErr != nil || pathConfig.Claim() {
+			break
+		}
+	}
 	var responseConfig config.ResponseConfig
+	if matched {
+		meta.ConfigName = pathConfig.Name
+		if pathConfig.Logging != nil {
+			meta.RedactHeaders = pathConfig.Logging.RedactHeaders
+		}
+		matchSpan.SetAttributes(attribute.String("echo.config", pathConfig.Name))
+		trace.SpanFromContext(r.Context()).SetName(r.Method + " " + pathConfig.CounterKey())
+	}
+	matchSpan.SetAttributes(attribute.Bool("echo.matched", matched))
+	matchSpan.End()
 
-	if pathConfig.Proxy != nil {
-		// create an http requet to forward to the proxy
-		proxyReq, err := http.NewRequest(r.Method, pathConfig.Proxy.URL, r.Body)
-		if err != nil {
-			logger.Error("Failed to create proxy request: %v", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	if !matched {
+		entry := journal.Entry{
+			Time:       time.Now(),
+			RequestID:  meta.RequestID,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      r.URL.RawQuery,
+			Headers:    journalHeaders(r.Header, nil),
+			RemoteAddr: r.RemoteAddr,
+		}
+		// Near misses are only needed right away for the strict 404
+		pm, path, method := h.config.PathMatcher, r.URL.Path, r.Method
+		nearest := func() []config.NearMiss {
+			return config.NearestConfigs(pm.GetAllConfigs(), path, method, nearMissLimit)
+		}
+		var closest []config.NearMiss
+		if h.config.Strict {
+			closest = nearest()
+			entry.Closest = closest
+		} else {
+			entry.Nearest = nearest
+		}
+		namespace.Unmatched(r.Context()).Add(entry)
+		if h.config.Strict {
+			log.Warn("No path config matches %s %s", r.Method, r.URL.Path)
+			writeUnmatched(w, r, closest)
 			return
 		}
-		proxyReq.Header.Set("X-Forwarded-For", r.RemoteAddr)
-		proxyReq.Header.Set("X-Forwarded-Proto", r.URL.Scheme)
-		proxyReq.Header.Set("X-Forwarded-Host", r.Host)
-		proxyReq.Header.Set("X-Forwarded-Method", r.Method)
+	}
 
-		client := &http.Client{}
-		proxyResp, err := client.Do(proxyReq)
-		if err != nil {
-			logger.Error("Failed to forward request to proxy: %v", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	isWebSocket := matched && pathConfig.WebSocket != nil && websocket.IsWebSocketUpgrade(r)
+
+	if matched && pathConfig.Logging != nil {
+		log = routeLogger(log, pathConfig.Logging, data)
+		r = r.WithContext(logger.NewContext(r.Context(), log))
+		if pathConfig.Logging.LogResponseBody && !isWebSocket {
+			capture := newCaptureWriter(w, pathConfig.Logging.BodyLimit())
+			defer capture.dump(log, pathConfig.Logging)
+			w = capture
+		}
+	}
+
+	if vc != nil {
+		valid := h.answerInvalid(w, r, vc, report, checkErr)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.Bool("echo.request_valid", valid))
+		if !valid {
 			return
 		}
-		defer proxyResp.Body.Close()
+	}
 
-		/*
-			for key, value := range proxyResp.Header {
-				w.Header()[key] = value
-			}
-			w.WriteHeader(proxyResp.StatusCode)
-		*/
-		body, err := io.ReadAll(proxyResp.Body)
-		fmt.Println("%v", body)
-		if err != nil {
-			logger.Error("Failed to read proxy response body: %v", err)
-		} else {
-			data.Body = string(body)
-			logger.Debug("Received proxy response: %s", data.Body)
+	// Rejected requests don't count as calls of the config
+	if matched {
+		c.IncrementConfig(pathConfig.CounterKey())
+	}
 
-		}
+	if isWebSocket {
+		h.serveWebSocket(w, r, pathConfig, data)
+		return
 	}
 
-	// Get current path count
-	pathCount := c.GetPathCount(r.URL.Path)
+	if matched && pathConfig.Proxy != nil {
+		meta.ProxyTarget = pathConfig.Proxy.URL
+		if err := h.forwardToProxy(r, pathConfig.Proxy, data); err != nil {
+			log.Error("Failed to forward request to proxy: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
 
-	shouldError := matched && h.shouldReturnError(pathConfig, pathCount)
+	shouldError := matched && h.shouldReturnError(log, pathConfig, errorEveryCount(c, pathConfig, r))
+	meta.ErrorInjected = shouldError
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Bool("echo.error_injected", shouldError))
 
 	if shouldError {
 		responseConfig = *pathConfig.ErrorResponse
 	} else if matched {
-		responseConfig = pathConfig.Response
+		var position uint64
+		if len(pathConfig.Responses) > 0 {
+			position = c.IncrementPosition(pathConfig.CounterKey())
+		}
+		var index int
+		responseConfig, index = pathConfig.ResponseFor(int64(position))
+		if index >= 0 {
+			log.Debug("Using response %d of %d for request %d", index+1, len(pathConfig.Responses), position)
+		}
 	} else {
 		responseConfig = h.config.DefaultResponse
 	}
@@ -135,8 +232,12 @@ func (h *EchoHandler) handleResponse(w http.ResponseWriter, r *http.Request, dat
 
 	// Apply configured delay if any
 	if responseConfig.Delay.Duration > 0 {
-		logger.Debug("Delaying response for %v", responseConfig.Delay.Duration)
+		log.Debug("Delaying response for %v", responseConfig.Delay.Duration)
+		meta.Delay = responseConfig.Delay.Duration
+		_, delaySpan := tracing.Tracer().Start(r.Context(), "delay",
+			trace.WithAttributes(attribute.String("echo.delay", responseConfig.Delay.String())))
 		time.Sleep(responseConfig.Delay.Duration)
+		delaySpan.End()
 	}
 
 	// Set response headers
@@ -151,10 +252,16 @@ func (h *EchoHandler) handleResponse(w http.ResponseWriter, r *http.Request, dat
 	var responseBody interface{}
 	if responseConfig.Body != "" {
 		var err error
-		responseBody, err = h.processResponseBody(responseConfig.Body, data)
-		logger.Debug("Processed response body: %v", responseBody)
+		_, templateSpan := tracing.Tracer().Start(r.Context(), "render",
+			trace.WithAttributes(attribute.Bool("echo.template", strings.HasPrefix(responseConfig.Body, "template:"))))
+		responseBody, err = h.processResponseBody(log, responseConfig.Body, data)
+		if err != nil {
+			templateSpan.SetStatus(codes.Error, err.Error())
+		}
+		templateSpan.End()
+		log.Debug("Processed response body: %v", responseBody)
 		if err != nil {
-			logger.Error("Failed to process response body: %v", err)
+			log.Error("Failed to process response body: %v", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
@@ -184,28 +291,72 @@ func (h *EchoHandler) handleResponse(w http.ResponseWriter, r *http.Request, dat
 			w.Write([]byte(str))
 		} else {
 			if err := json.NewEncoder(w).Encode(responseBody); err != nil {
-				logger.Error("Failed to encode response: %v", err)
+				log.Error("Failed to encode response: %v", err)
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 				return
 			}
 		}
 	} else {
 		if err := json.NewEncoder(w).Encode(response); err != nil {
-			logger.Error("Failed to encode response: %v", err)
+			log.Error("Failed to encode response: %v", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 	}
 }
 
+// nearMissLimit is the number of closest configs reported for unmatched requests
+const nearMissLimit = 3
+
+// UnmatchedResponse is the 404 body returned in strict mode
+type UnmatchedResponse struct {
+	Error   string            `json:"error"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Closest []config.NearMiss `json:"closest"`
+}
+
+func writeUnmatched(w http.ResponseWriter, r *http.Request, closest []config.NearMiss) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(newUnmatchedResponse(r.Method, r.URL.Path, closest))
+}
+
+func newUnmatchedResponse(method, path string, closest []config.NearMiss) UnmatchedResponse {
+	return UnmatchedResponse{
+		Error:   "no path config matches " + method + " " + path,
+		Method:  method,
+		Path:    path,
+		Closest: closest,
+	}
+}
+
 func (h *EchoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Extract request data
 	data, err := model.ExtractRequestData(r)
 	if err != nil {
-		logger.Error("Failed to extract request data: %v", err)
+		logger.FromContext(r.Context()).Error("Failed to extract request data: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	h.handleResponse(w, r, data)
+	// Requests sent to a namespace are answered from its configuration
+	if ns, ok := namespace.FromContext(r.Context()); ok {
+		h = &EchoHandler{config: ns.Config}
+	}
+
+	// Keep the meta in the context so the matched config can be journaled
+	meta := model.RequestMetaFromContext(r.Context())
+	r = r.WithContext(model.WithRequestMeta(r.Context(), meta))
+
+	start := time.Now()
+	if websocket.IsWebSocketUpgrade(r) {
+		// The connection is hijacked, there is no response to record
+		h.handleResponse(w, r, data)
+		recordRequest(r, data, meta, start, nil)
+		return
+	}
+	capture := newCaptureWriter(w, journal.MaxBodyBytes)
+	h.handleResponse(capture, r, data)
+	recordRequest(r, data, meta, start, capture)
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -8,7 +9,10 @@ import (
 	"time"
 
 	"echo-server/internal/config"
+	"echo-server/internal/counter"
+	"echo-server/internal/journal"
 	"echo-server/internal/middleware"
+	"echo-server/internal/model"
 )
 
 func TestEchoHandler_ConfigLookup(t *testing.T) {
@@ -439,3 +443,241 @@ func TestErrorEvery(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestID(t *testing.T) {
+	cfg := &config.ServerConfig{
+		PathMatcher: config.NewPathMatcher(),
+	}
+	handler := middleware.RequestLogging(NewEchoHandler(cfg))
+
+	tests := []struct {
+		name     string
+		incoming string
+		replaced bool
+	}{
+		{name: "propagated from request", incoming: "client-supplied-id"},
+		{name: "generated"},
+		{name: "control characters replaced", incoming: "id\r\nforged=1", replaced: true},
+		{name: "overlong replaced", incoming: strings.Repeat("a", 129), replaced: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/request-id", nil)
+			if tt.incoming != "" {
+				req.Header[middleware.RequestIDHeader] = []string{tt.incoming}
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			if id == "" || (tt.incoming != "" && (id == tt.incoming) == tt.replaced) {
+				t.Errorf("%s header = %q for incoming %q", middleware.RequestIDHeader, id, tt.incoming)
+			}
+
+			var echo model.RequestData
+			if err := json.NewDecoder(w.Body).Decode(&echo); err != nil {
+				t.Fatalf("Failed to decode echo: %v", err)
+			}
+			if echo.RequestID != id {
+				t.Errorf("Echoed requestId = %q, want %q", echo.RequestID, id)
+			}
+		})
+	}
+}
+
+func TestErrorEveryCounter(t *testing.T) {
+	pathConfig := config.PathConfig{
+		Name:              "error-every-config",
+		Pattern:           "^/ids/.*$",
+		ErrorEvery:        2,
+		ErrorEveryCounter: config.CounterConfig,
+		ErrorResponse: &config.ResponseConfig{
+			StatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	cfg := &config.ServerConfig{
+		PathMatcher: config.NewPathMatcher(),
+	}
+	if err := cfg.PathMatcher.Add(&pathConfig); err != nil {
+		t.Fatalf("Failed to add path config: %v", err)
+	}
+	counter.GetGlobalCounter().ResetConfig(pathConfig.Name)
+	handler := middleware.RequestLogging(NewEchoHandler(cfg))
+
+	// Different raw paths share the config counter
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/ids/a", http.StatusOK},
+		{"/ids/b", http.StatusServiceUnavailable},
+		{"/ids/c", http.StatusOK},
+		{"/ids/d", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s status = %d, want %d", tt.path, w.Code, tt.wantStatus)
+		}
+	}
+
+	if err := config.NewPathMatcher().Add(&config.PathConfig{Pattern: "^/x$", ErrorEveryCounter: "header"}); err == nil {
+		t.Error("Expected invalid errorEveryCounter to be rejected")
+	}
+}
+
+func TestSequencedResponses(t *testing.T) {
+	responses := func(bodies ...string) []config.WeightedResponse {
+		list := make([]config.WeightedResponse, len(bodies))
+		for i, body := range bodies {
+			list[i].Body = body
+		}
+		return list
+	}
+
+	tests := []struct {
+		name  string
+		order string
+		want  []string
+	}{
+		{name: "sequence sticks on the last", order: "", want: []string{"pending", "pending", "done", "done"}},
+		{name: "loop", order: config.ResponseOrderLoop, want: []string{"pending", "pending", "done", "pending"}},
+	}
+
+	c := counter.GetGlobalCounter()
+	t.Cleanup(c.Reset)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.Reset()
+			cfg := &config.ServerConfig{PathMatcher: config.NewPathMatcher()}
+			if err := cfg.PathMatcher.Add(&config.PathConfig{
+				Pattern:       "^/jobs/1$",
+				Responses:     responses("pending", "pending", "done"),
+				ResponseOrder: tt.order,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			handler := NewEchoHandler(cfg)
+
+			for i, want := range tt.want {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest("GET", "/jobs/1", nil))
+				if got := w.Body.String(); got != want {
+					t.Errorf("request %d body = %q, want %q", i+1, got, want)
+				}
+			}
+		})
+	}
+
+	// Retry tests: the third attempt succeeds
+	cfg := &config.ServerConfig{PathMatcher: config.NewPathMatcher()}
+	if err := cfg.PathMatcher.Add(&config.PathConfig{
+		Pattern: "^/payments$",
+		Responses: []config.WeightedResponse{
+			{ResponseConfig: config.ResponseConfig{StatusCode: http.StatusServiceUnavailable}},
+			{ResponseConfig: config.ResponseConfig{StatusCode: http.StatusServiceUnavailable}},
+			{ResponseConfig: config.ResponseConfig{StatusCode: http.StatusOK}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewEchoHandler(cfg)
+	attempt := func(want int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/payments", nil))
+		if w.Code != want {
+			t.Errorf("attempt status = %d, want %d", w.Code, want)
+		}
+	}
+	for _, want := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK} {
+		attempt(want)
+	}
+
+	// The position is a counter: it is snapshotted and can be reset
+	if got := c.Snapshot().Positions["^/payments$"]; got != 3 {
+		t.Errorf("snapshot position = %d, want 3", got)
+	}
+	c.ResetPosition("^/payments$")
+	attempt(http.StatusServiceUnavailable)
+	c.SetPosition("^/payments$", 2)
+	attempt(http.StatusOK)
+
+	if err := config.NewPathMatcher().Add(&config.PathConfig{Pattern: "^/x$", ResponseOrder: "shuffle"}); err == nil {
+		t.Error("Expected invalid responseOrder to be rejected")
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	pm := config.NewPathMatcher()
+	if err := pm.Add(&config.PathConfig{Name: "users", Pattern: "^/users$", Methods: []string{"GET"}}); err != nil {
+		t.Fatal(err)
+	}
+	journal.Unmatched().Clear()
+	t.Cleanup(journal.Unmatched().Clear)
+
+	tests := []struct {
+		name       string
+		strict     bool
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"matched", true, "GET", "/users", http.StatusOK},
+		{"lenient fallback", false, "GET", "/userz", http.StatusOK},
+		{"strict method mismatch", true, "POST", "/users", http.StatusNotFound},
+		{"strict unknown path", true, "GET", "/user", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewEchoHandler(&config.ServerConfig{PathMatcher: pm, Strict: tt.strict})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Cookie", "session=secret")
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusNotFound {
+				return
+			}
+			var resp UnmatchedResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Closest) != 1 || resp.Closest[0].Name != "users" {
+				t.Errorf("closest = %+v, want users", resp.Closest)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	UnmatchedHandler(w, httptest.NewRequest("GET", "/unmatched", nil))
+	var list UnmatchedListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 3 || len(list.Requests) != 3 || list.Requests[0].Path != "/userz" {
+		t.Errorf("unmatched = %+v, want the 3 unmatched requests oldest first", list)
+	}
+	// Near misses of lenient requests are computed when the list is read
+	if closest := list.Requests[0].Closest; len(closest) != 1 || closest[0].Name != "users" {
+		t.Errorf("closest of lenient request = %+v, want users", closest)
+	}
+	if got := list.Requests[0].Headers.Get("Cookie"); got != "[REDACTED]" {
+		t.Errorf("Cookie = %q, want it masked", got)
+	}
+
+	UnmatchedHandler(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/unmatched", nil))
+	if got := journal.Unmatched().Total(); got != 0 {
+		t.Errorf("total after DELETE = %d, want 0", got)
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"echo-server/internal/config"
+	"echo-server/internal/namespace"
+	"echo-server/internal/postman"
+	"echo-server/pkg/logger"
+)
+
+// ExampleRequest is one way to invoke a path config
+type ExampleRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Curl   string `json:"curl"`
+}
+
+// ConfigExample lists example requests for a path config, one per method.
+// Path is empty and Requests is empty when no path matching the pattern
+// could be generated.
+type ConfigExample struct {
+	Name     string           `json:"name"`
+	Pattern  string           `json:"pattern"`
+	Path     string           `json:"path,omitempty"`
+	Requests []ExampleRequest `json:"requests"`
+}
+
+// configExamples generates example requests for every loaded config against
+// baseURL
+func configExamples(configs []config.PathConfig, baseURL string) []ConfigExample {
+	examples := make([]ConfigExample, 0, len(configs))
+	for i := range configs {
+		pc := &configs[i]
+		example := ConfigExample{Name: pc.Name, Pattern: pc.Pattern, Requests: []ExampleRequest{}}
+		if path, ok := pc.SamplePath(); ok {
+			example.Path = path
+			for _, method := range exampleMethods(pc) {
+				example.Requests = append(example.Requests, ExampleRequest{
+					Method: method,
+					URL:    baseURL + path,
+					Curl:   curlCommand(method, baseURL+path),
+				})
+			}
+		}
+		examples = append(examples, example)
+	}
+	return examples
+}
+
+// exampleMethods returns the methods a config accepts, GET for configs
+// accepting all of them
+func exampleMethods(pc *config.PathConfig) []string {
+	if len(pc.Methods) == 0 {
+		return []string{http.MethodGet}
+	}
+	return pc.Methods
+}
+
+func curlCommand(method, url string) string {
+	if method == http.MethodGet {
+		return "curl " + shellQuote(url)
+	}
+	if method == http.MethodHead {
+		return "curl -I " + shellQuote(url)
+	}
+	return "curl -X " + method + " " + shellQuote(url)
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// mockBaseURL returns the URL the mocks are reached on, followed by
+// /ns/{name} for requests sent to a namespace
+func mockBaseURL(r *http.Request, cfg *config.ServerConfig) string {
+	base := serverBaseURL(r, cfg)
+	if ns, ok := namespace.FromContext(r.Context()); ok {
+		base += namespace.PathPrefix + ns.Name
+	}
+	return base
+}
+
+// serverBaseURL returns the URL of the server the mocks run on. It is taken
+// from the baseUrl query parameter, from the request when the admin
+// endpoints share the mocks' listener, or from the server address otherwise.
+func serverBaseURL(r *http.Request, cfg *config.ServerConfig) string {
+	if base := r.URL.Query().Get("baseUrl"); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	if cfg.Admin != nil && cfg.Admin.Port != 0 {
+		scheme := "http"
+		if cfg.TLS != nil {
+			scheme = "https"
+		}
+		host := cfg.Host
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "localhost"
+		}
+		return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// handleExamples returns example requests with curl commands for every
+// config
+func (h *ConfigurationHandler) handleExamples(w http.ResponseWriter, r *http.Request) {
+	cfg := h.serverConfig(r)
+	examples := configExamples(cfg.PathMatcher.GetAllConfigs(), mockBaseURL(r, cfg))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(examples); err != nil {
+		logger.Error("Failed to encode config examples: %v", err)
+	}
+}
+
+// handleExportPostman returns a Postman v2.1 collection with a request per
+// config and method. Requests are relative to the baseUrl variable.
+func (h *ConfigurationHandler) handleExportPostman(w http.ResponseWriter, r *http.Request) {
+	cfg := h.serverConfig(r)
+	collection := postman.New("echo-server", mockBaseURL(r, cfg))
+
+	for _, example := range configExamples(cfg.PathMatcher.GetAllConfigs(), "") {
+		name := example.Name
+		if name == "" {
+			name = example.Pattern
+		}
+		if example.Path == "" {
+			logger.Warn("No sample path for pattern %s, left out of the Postman collection", example.Pattern)
+			continue
+		}
+		for _, req := range example.Requests {
+			itemName := name
+			if len(example.Requests) > 1 {
+				itemName += " (" + req.Method + ")"
+			}
+			collection.Item = append(collection.Item, postman.Item{
+				Name: itemName,
+				Request: postman.Request{
+					Method:      req.Method,
+					Header:      []postman.Header{},
+					URL:         postman.NewURL(example.Path),
+					Description: "Matches " + example.Pattern,
+				},
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="echo-server.postman_collection.json"`)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(collection); err != nil {
+		logger.Error("Failed to encode Postman collection: %v", err)
+	}
+}
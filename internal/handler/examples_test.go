@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/namespace"
+	"echo-server/internal/postman"
+)
+
+func TestConfigExamples(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher()})
+	for _, pc := range []config.PathConfig{
+		{Name: "user", Pattern: `^/users/[0-9]+$`, Methods: []string{"GET", "DELETE"}},
+		{Name: "search", Pattern: `^/search/(books|films)$`},
+		{Name: "boundary", Pattern: `^/a\bb$`},
+	} {
+		if err := cm.UpdatePathConfig(pc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewConfigurationHandler(cm)
+
+	t.Run("examples", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "http://mock.local:8080/config/examples", nil))
+
+		var examples []ConfigExample
+		if err := json.NewDecoder(rr.Body).Decode(&examples); err != nil {
+			t.Fatal(err)
+		}
+		want := []ConfigExample{
+			{Name: "user", Pattern: `^/users/[0-9]+$`, Path: "/users/0", Requests: []ExampleRequest{
+				{Method: "GET", URL: "http://mock.local:8080/users/0", Curl: "curl 'http://mock.local:8080/users/0'"},
+				{Method: "DELETE", URL: "http://mock.local:8080/users/0", Curl: "curl -X DELETE 'http://mock.local:8080/users/0'"},
+			}},
+			{Name: "search", Pattern: `^/search/(books|films)$`, Path: "/search/books", Requests: []ExampleRequest{
+				{Method: "GET", URL: "http://mock.local:8080/search/books", Curl: "curl 'http://mock.local:8080/search/books'"},
+			}},
+			{Name: "boundary", Pattern: `^/a\bb$`, Requests: []ExampleRequest{}},
+		}
+		if !reflect.DeepEqual(examples, want) {
+			t.Errorf("examples = %+v\nwant %+v", examples, want)
+		}
+	})
+
+	t.Run("postman", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/config/export/postman?baseUrl=https://staging.example.com/", nil))
+
+		var collection postman.Collection
+		if err := json.NewDecoder(rr.Body).Decode(&collection); err != nil {
+			t.Fatal(err)
+		}
+		if collection.Info.Schema != postman.SchemaURL {
+			t.Errorf("schema = %q", collection.Info.Schema)
+		}
+		if want := []postman.Variable{{Key: "baseUrl", Value: "https://staging.example.com"}}; !reflect.DeepEqual(collection.Variable, want) {
+			t.Errorf("variables = %+v, want %+v", collection.Variable, want)
+		}
+
+		var names, urls []string
+		for _, item := range collection.Item {
+			names = append(names, item.Request.Method+" "+item.Name)
+			urls = append(urls, item.Request.URL.Raw)
+		}
+		if want := []string{"GET user (GET)", "DELETE user (DELETE)", "GET search"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("items = %v, want %v", names, want)
+		}
+		if want := []string{"{{baseUrl}}/users/0", "{{baseUrl}}/users/0", "{{baseUrl}}/search/books"}; !reflect.DeepEqual(urls, want) {
+			t.Errorf("urls = %v, want %v", urls, want)
+		}
+		if path := collection.Item[0].Request.URL.Path; !reflect.DeepEqual(path, []string{"users", "0"}) {
+			t.Errorf("path = %v", path)
+		}
+	})
+}
+
+func TestMockBaseURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		cfg       config.ServerConfig
+		namespace string
+		want      string
+	}{
+		{name: "same listener", target: "http://mock.local:8080/config/examples", want: "http://mock.local:8080"},
+		{name: "query parameter", target: "/config/examples?baseUrl=http://gw/mock/", want: "http://gw/mock"},
+		{
+			name:   "admin listener",
+			target: "http://localhost:9090/config/examples",
+			cfg:    config.ServerConfig{Host: "0.0.0.0", Port: 8443, TLS: &config.TLSConfig{SelfSigned: true}, Admin: &config.AdminConfig{Port: 9090}},
+			want:   "https://localhost:8443",
+		},
+		{name: "namespace", target: "http://mock.local:8080/config/examples", namespace: "team-a", want: "http://mock.local:8080/ns/team-a"},
+		{name: "namespace and query parameter", target: "/config/examples?baseUrl=http://gw/mock/", namespace: "team-a", want: "http://gw/mock/ns/team-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.namespace != "" {
+				ns, err := namespace.NewRegistry().Create(tt.namespace, &config.ServerConfig{PathMatcher: config.NewPathMatcher()}, 0, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = r.WithContext(namespace.NewContext(r.Context(), ns))
+			}
+			if got := mockBaseURL(r, &tt.cfg); got != tt.want {
+				t.Errorf("mockBaseURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
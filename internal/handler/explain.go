@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/config"
+	"echo-server/internal/model"
+	"echo-server/internal/namespace"
+	"echo-server/internal/openapi"
+	"echo-server/pkg/logger"
+)
+
+// ExplainRequest describes the request to explain. Path may include a
+// query string.
+type ExplainRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// ExplainCandidate is one path config and how it compared to the request
+type ExplainCandidate struct {
+	Name           string   `json:"name,omitempty"`
+	Pattern        string   `json:"pattern"`
+	Methods        []string `json:"methods,omitempty"`
+	PatternMatched bool     `json:"patternMatched"`
+	MethodMatched  bool     `json:"methodMatched"`
+	Selected       bool     `json:"selected"`
+}
+
+// ExplainErrorInjection shows how ErrorEvery applies to the request. Next is
+// the counter value the request would see once counted.
+type ExplainErrorInjection struct {
+	Counter    string `json:"counter"`
+	Current    uint64 `json:"current"`
+	Next       uint64 `json:"next"`
+	ErrorEvery int    `json:"errorEvery"`
+	Injected   bool   `json:"injected"`
+}
+
+// ExplainResponse is the response the request would get. Source is
+// "response", "responses[i]", "errorResponse", "defaultResponse", "strict",
+// "requestValidation", "proxy" or "websocket". For configs with random
+// responses it is "responses[random]" without the response, any of them
+// can be picked.
+type ExplainResponse struct {
+	Source     string            `json:"source"`
+	StatusCode int               `json:"statusCode,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       interface{}       `json:"body,omitempty"`
+	Delay      config.Duration   `json:"delay"`
+	ProxyURL   string            `json:"proxyUrl,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// ExplainResult is returned by /config/explain
+type ExplainResult struct {
+	Request        ExplainRequest         `json:"request"`
+	Candidates     []ExplainCandidate     `json:"candidates"`
+	Matched        bool                   `json:"matched"`
+	Config         *config.PathConfig     `json:"config,omitempty"`
+	ErrorInjection *ExplainErrorInjection `json:"errorInjection,omitempty"`
+	// Validation lists the violations when the request would be rejected
+	Validation *openapi.ValidationReport `json:"validation,omitempty"`
+	Response   ExplainResponse           `json:"response"`
+}
+
+// ErrorInjected reports whether the explained request would get the error
+// response
+func (e *ExplainResult) ErrorInjected() bool {
+	return e.ErrorInjection != nil && e.ErrorInjection.Injected
+}
+
+// handleExplain reports which config a request would match and what it
+// would get back, without counting it or contacting proxies. The request is
+// given as JSON in a POST body or as method and path query parameters.
+func (h *ConfigurationHandler) handleExplain(w http.ResponseWriter, r *http.Request) {
+	var req ExplainRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Method = r.URL.Query().Get("method")
+		req.Path = r.URL.Query().Get("path")
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	req.Method = strings.ToUpper(req.Method)
+	if !strings.HasPrefix(req.Path, "/") {
+		http.Error(w, "path must start with /", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.explain(r, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logger.Error("Failed to encode explain response: %v", err)
+	}
+}
+
+func (h *ConfigurationHandler) explain(r *http.Request, req ExplainRequest) (*ExplainResult, error) {
+	target, err := http.NewRequestWithContext(r.Context(), req.Method, req.Path, strings.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	target.Host = r.Host
+	target.RemoteAddr = r.RemoteAddr
+	for key, value := range req.Headers {
+		if strings.EqualFold(key, "Host") {
+			target.Host = value
+			continue
+		}
+		target.Header.Set(key, value)
+	}
+
+	data, err := model.ExtractRequestData(target)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := h.serverConfig(r)
+	result := &ExplainResult{Request: req, Candidates: []ExplainCandidate{}}
+	var selected *config.PathConfig
+	for _, pc := range config.MatchOrder(cfg.PathMatcher.GetAllConfigs()) {
+		candidate := ExplainCandidate{
+			Name:           pc.Name,
+			Pattern:        pc.Pattern,
+			Methods:        pc.Methods,
+			PatternMatched: pc.MatchesPattern(target.URL.Path),
+			MethodMatched:  pc.MatchesMethod(target.Method),
+		}
+		if selected == nil && candidate.PatternMatched && candidate.MethodMatched {
+			candidate.Selected = true
+			selected = &pc
+		}
+		result.Candidates = append(result.Candidates, candidate)
+	}
+
+	result.Matched = selected != nil
+	result.Config = selected
+	if selected == nil && cfg.Strict {
+		// Strict mode answers 404 with the closest configs instead
+		closest := config.NearestConfigs(cfg.PathMatcher.GetAllConfigs(), target.URL.Path, target.Method, nearMissLimit)
+		result.Response = ExplainResponse{
+			Source:     "strict",
+			StatusCode: http.StatusNotFound,
+			Body:       newUnmatchedResponse(target.Method, target.URL.Path, closest),
+		}
+		return result, nil
+	}
+	responseConfig := cfg.DefaultResponse
+	result.Response.Source = "defaultResponse"
+
+	if selected != nil {
+		var index int
+		responseConfig, index = selected.ResponseFor(int64(namespace.Counter(r.Context()).GetPosition(selected.CounterKey())) + 1)
+		result.Response.Source = "response"
+		switch {
+		case index >= 0 && selected.ResponseOrder == config.ResponseOrderRandom:
+			result.Response.Source = "responses[random]"
+		case index >= 0:
+			result.Response.Source = fmt.Sprintf("responses[%d]", index)
+		}
+
+		if selected.ErrorEvery > 0 {
+			current := errorEveryCount(namespace.Counter(r.Context()), selected, target)
+			injection := &ExplainErrorInjection{
+				Counter:    selected.ErrorEveryCounter,
+				Current:    current,
+				Next:       current + 1,
+				ErrorEvery: selected.ErrorEvery,
+				Injected:   errorDue(selected, current+1),
+			}
+			if injection.Counter == "" {
+				injection.Counter = config.CounterPath
+			}
+			result.ErrorInjection = injection
+			if injection.Injected {
+				responseConfig = *selected.ErrorResponse
+				result.Response.Source = "errorResponse"
+			}
+		}
+	}
+
+	if vc := NewEchoHandler(cfg).requestValidation(selected, selected != nil); vc != nil {
+		report, err := checkRequest(target, vc, data)
+		if err != nil {
+			return nil, err
+		}
+		if report != nil {
+			result.Validation = report
+			result.Response = ExplainResponse{Source: "requestValidation", StatusCode: vc.ResponseStatus(), Headers: vc.Headers, Body: report}
+			return result, nil
+		}
+	}
+
+	if selected != nil {
+		switch {
+		case selected.WebSocket != nil && strings.EqualFold(target.Header.Get("Upgrade"), "websocket"):
+			result.Response = ExplainResponse{Source: "websocket"}
+			return result, nil
+		case selected.Proxy != nil && !result.ErrorInjected():
+			// The response comes from the upstream, only the target is known
+			result.Response = ExplainResponse{Source: "proxy", ProxyURL: selected.Proxy.URL}
+			return result, nil
+		}
+	}
+
+	if result.Response.Source == "responses[random]" {
+		return result, nil
+	}
+	if responseConfig.StatusCode == 0 {
+		responseConfig.StatusCode = http.StatusOK
+	}
+	result.Response.StatusCode = responseConfig.StatusCode
+	result.Response.Headers = responseConfig.Headers
+	result.Response.Delay = responseConfig.Delay
+
+	switch {
+	case responseConfig.Body != "":
+		body, err := NewEchoHandler(cfg).processResponseBody(logger.FromContext(r.Context()), responseConfig.Body, data)
+		if err != nil {
+			result.Response.Error = err.Error()
+		}
+		result.Response.Body = body
+	default:
+		// Without a body the request is echoed back
+		result.Response.Body = data
+	}
+	return result, nil
+}
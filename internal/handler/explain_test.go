@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+)
+
+func TestConfigExplain(t *testing.T) {
+	c := counter.GetGlobalCounter()
+	c.Reset()
+	t.Cleanup(c.Reset)
+
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{
+		PathMatcher:     config.NewPathMatcher(),
+		DefaultResponse: config.ResponseConfig{StatusCode: http.StatusTeapot},
+	})
+	for _, pc := range []config.PathConfig{
+		{Name: "users-post", Pattern: "^/users$", Methods: []string{"POST"}},
+		{
+			Name:          "users",
+			Pattern:       "^/users.*",
+			Response:      config.ResponseConfig{StatusCode: 200, Body: `template:{"path":"{{.Path}}","q":"{{index .QueryParams "q" 0}}"}`},
+			ErrorResponse: &config.ResponseConfig{StatusCode: 503, Body: "down"},
+			ErrorEvery:    3,
+		},
+		{Name: "upstream", Pattern: "^/proxy", Proxy: &config.ProxyConfig{URL: "http://upstream:9000"}},
+		{
+			Name:    "jobs",
+			Pattern: "^/jobs$",
+			Responses: []config.WeightedResponse{
+				{ResponseConfig: config.ResponseConfig{StatusCode: 202}},
+				{ResponseConfig: config.ResponseConfig{StatusCode: 200}},
+			},
+		},
+		{
+			Name:          "dice",
+			Pattern:       "^/dice$",
+			ResponseOrder: config.ResponseOrderRandom,
+			Responses: []config.WeightedResponse{
+				{ResponseConfig: config.ResponseConfig{StatusCode: 200}},
+				{ResponseConfig: config.ResponseConfig{StatusCode: 500}},
+			},
+		},
+	} {
+		if err := cm.UpdatePathConfig(pc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewConfigurationHandler(cm)
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		body         string
+		setup        func()
+		wantMatched  string
+		wantSource   string
+		wantStatus   int
+		wantBody     interface{}
+		wantSelected []bool
+	}{
+		{
+			name:         "method mismatch falls through",
+			method:       "POST",
+			target:       "/config/explain",
+			body:         `{"method": "GET", "path": "/users?q=bob"}`,
+			wantMatched:  "users",
+			wantSource:   "response",
+			wantStatus:   200,
+			wantBody:     map[string]interface{}{"path": "/users", "q": "bob"},
+			wantSelected: []bool{false, true, false},
+		},
+		{
+			name:        "error at next counter value",
+			method:      "POST",
+			target:      "/config/explain",
+			body:        `{"method": "GET", "path": "/users/1"}`,
+			setup:       func() { c.SetPath("/users/1", 2) },
+			wantMatched: "users",
+			wantSource:  "errorResponse",
+			wantStatus:  503,
+			wantBody:    "down",
+		},
+		{
+			name:        "proxy",
+			method:      "GET",
+			target:      "/config/explain?method=get&path=/proxy/x",
+			wantMatched: "upstream",
+			wantSource:  "proxy",
+		},
+		{
+			name:        "next of the responses",
+			method:      "GET",
+			target:      "/config/explain?path=/jobs",
+			setup:       func() { c.SetPosition("jobs", 1) },
+			wantMatched: "jobs",
+			wantSource:  "responses[1]",
+			wantStatus:  200,
+		},
+		{
+			name:        "random responses",
+			method:      "GET",
+			target:      "/config/explain?path=/dice",
+			wantMatched: "dice",
+			wantSource:  "responses[random]",
+		},
+		{
+			name:       "default response",
+			method:     "GET",
+			target:     "/config/explain?path=/nothing",
+			wantSource: "defaultResponse",
+			wantStatus: http.StatusTeapot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+
+			var result ExplainResult
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Matched != (tt.wantMatched != "") || (result.Config != nil && result.Config.Name != tt.wantMatched) {
+				t.Errorf("matched = %v (%v), want %q", result.Matched, result.Config, tt.wantMatched)
+			}
+			if result.Response.Source != tt.wantSource || result.Response.StatusCode != tt.wantStatus {
+				t.Errorf("response = %s %d, want %s %d", result.Response.Source, result.Response.StatusCode, tt.wantSource, tt.wantStatus)
+			}
+			if tt.wantBody != nil {
+				got, _ := json.Marshal(result.Response.Body)
+				want, _ := json.Marshal(tt.wantBody)
+				if string(got) != string(want) {
+					t.Errorf("body = %s, want %s", got, want)
+				}
+			}
+			for i, want := range tt.wantSelected {
+				if result.Candidates[i].Selected != want {
+					t.Errorf("candidate %d selected = %v, want %v", i, result.Candidates[i].Selected, want)
+				}
+			}
+		})
+	}
+
+	if got := c.GetPathCount("/users"); got != 0 {
+		t.Errorf("explain counted the request: %d", got)
+	}
+}
+
+func TestConfigExplainStrict(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher(), Strict: true})
+	if err := cm.UpdatePathConfig(config.PathConfig{Name: "users", Pattern: "^/users$"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	NewConfigurationHandler(cm).ServeHTTP(w, httptest.NewRequest("GET", "/config/explain?path=/user", nil))
+	var result struct {
+		Matched  bool
+		Response struct {
+			Source     string
+			StatusCode int
+			Body       UnmatchedResponse
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Matched || result.Response.Source != "strict" || result.Response.StatusCode != http.StatusNotFound {
+		t.Errorf("response = %+v, want the strict 404", result.Response)
+	}
+	if closest := result.Response.Body.Closest; len(closest) == 0 || closest[0].Name != "users" {
+		t.Errorf("closest = %+v, want users", closest)
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"echo-server/internal/config"
+	"echo-server/pkg/logger"
+)
+
+// AuthorHeader names who makes a config change in the history. Without it
+// the basic auth user or the client address is recorded.
+const AuthorHeader = "X-Config-Author"
+
+var errConfigNotFound = errors.New("configuration not found")
+
+// HistoryVersion is a version with the path configs it consists of
+type HistoryVersion struct {
+	config.ConfigVersion
+	Paths []config.PathConfig `json:"paths"`
+}
+
+// RollbackResponse describes a rollback. Version is the version recorded for
+// it, zero when the configs already were those of RolledBackTo.
+type RollbackResponse struct {
+	RolledBackTo int                   `json:"rolledBackTo"`
+	Version      int                   `json:"version,omitempty"`
+	Configs      int                   `json:"configs"`
+	Changes      []config.ConfigChange `json:"changes"`
+}
+
+// configAuthor identifies who sent a config change
+func configAuthor(r *http.Request) string {
+	if author := r.Header.Get(AuthorHeader); author != "" {
+		return author
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// updateConfigs changes the path configs with fn at once and records the
+// change in the history
+func (h *ConfigurationHandler) updateConfigs(r *http.Request, fn func(configs []config.PathConfig) ([]config.PathConfig, error)) (config.ConfigVersion, error) {
+	return h.updateConfigsFrom(r, r.Method+" "+r.URL.Path, fn)
+}
+
+// The version is recorded while the matcher is locked, so concurrent changes
+// are numbered in the order they were applied.
+func (h *ConfigurationHandler) updateConfigsFrom(r *http.Request, source string, fn func(configs []config.PathConfig) ([]config.PathConfig, error)) (config.ConfigVersion, error) {
+	cfg := h.serverConfig(r)
+	var version config.ConfigVersion
+	err := cfg.PathMatcher.Update(func(configs []config.PathConfig) ([]config.PathConfig, error) {
+		next, err := fn(append([]config.PathConfig(nil), configs...))
+		if err == nil {
+			err = config.CheckPathConfigs(next)
+		}
+		if err != nil {
+			return nil, err
+		}
+		version, _ = cfg.History.Record(configAuthor(r), source, configs, next)
+		return next, nil
+	})
+	if err != nil {
+		return config.ConfigVersion{}, err
+	}
+	return version, nil
+}
+
+// handleHistory lists the kept versions, oldest first, or with
+// /config/history/{version} one version with its path configs
+func (h *ConfigurationHandler) handleHistory(w http.ResponseWriter, r *http.Request, version string) {
+	history := h.serverConfig(r).History
+
+	var body interface{} = history.Versions()
+	if version != "" {
+		n, err := strconv.Atoi(version)
+		if err != nil {
+			http.Error(w, "Invalid version", http.StatusBadRequest)
+			return
+		}
+		v, ok := history.Version(n)
+		if !ok {
+			http.Error(w, "Version not found", http.StatusNotFound)
+			return
+		}
+		body = HistoryVersion{ConfigVersion: v, Paths: v.PathConfigs()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("Failed to encode config history: %v", err)
+	}
+}
+
+// handleRollback restores the path configs of a kept version. The rollback
+// is recorded as a new version, so it can be undone the same way.
+func (h *ConfigurationHandler) handleRollback(w http.ResponseWriter, r *http.Request, version string) {
+	n, err := strconv.Atoi(version)
+	if err != nil {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+	target, ok := h.serverConfig(r).History.Version(n)
+	if !ok {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+
+	recorded, err := h.updateConfigsFrom(r, "rollback to version "+version, func([]config.PathConfig) ([]config.PathConfig, error) {
+		return target.PathConfigs(), nil
+	})
+	if err != nil {
+		logger.Error("Failed to roll back to config version %d: %v", n, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("Rolled back path configs to version %d", n)
+
+	resp := RollbackResponse{RolledBackTo: n, Version: recorded.Version, Configs: target.Configs, Changes: recorded.Changes}
+	if resp.Changes == nil {
+		resp.Changes = []config.ConfigChange{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("Failed to encode rollback response: %v", err)
+	}
+}
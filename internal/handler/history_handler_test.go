@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"echo-server/internal/config"
+)
+
+func TestConfigHistory(t *testing.T) {
+	cm := config.NewConfigManager()
+	handler := NewConfigurationHandler(cm)
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(AuthorHeader, "ada")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	do(http.MethodPost, "/config/paths", `{"name": "users", "pattern": "^/users$", "response": {"statusCode": 200}}`)
+	do(http.MethodPost, "/config/batch", `{"operations": [
+		{"op": "update", "name": "users", "config": {"pattern": "^/users$", "response": {"statusCode": 500}}},
+		{"op": "create", "config": {"name": "orders", "pattern": "^/orders$"}}
+	]}`)
+	if rr := do(http.MethodDelete, "/config/paths/missing", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("deleting a missing config = %d, want 404", rr.Code)
+	}
+
+	rr := do(http.MethodGet, "/config/history", "")
+	var versions []config.ConfigVersion
+	if err := json.NewDecoder(rr.Body).Decode(&versions); err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("versions = %+v", versions)
+	}
+	second := versions[1]
+	if second.Version != 2 || second.Author != "ada" || second.Source != "POST /config/batch" || second.Configs != 2 {
+		t.Errorf("version 2 = %+v", second)
+	}
+	if len(second.Changes) != 2 || second.Changes[0].Op != config.ChangeUpdated || second.Changes[0].Fields[0] != "response" || second.Changes[1].Op != config.ChangeAdded {
+		t.Errorf("version 2 changes = %+v", second.Changes)
+	}
+
+	rr = do(http.MethodGet, "/config/history/1", "")
+	var first HistoryVersion
+	if err := json.NewDecoder(rr.Body).Decode(&first); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Paths) != 1 || first.Paths[0].Response.StatusCode != 200 {
+		t.Errorf("version 1 paths = %+v", first.Paths)
+	}
+
+	rr = do(http.MethodPost, "/config/rollback/1", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("rollback status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var rollback RollbackResponse
+	if err := json.NewDecoder(rr.Body).Decode(&rollback); err != nil {
+		t.Fatal(err)
+	}
+	if rollback.RolledBackTo != 1 || rollback.Version != 3 || rollback.Configs != 1 || len(rollback.Changes) != 2 {
+		t.Errorf("rollback = %+v", rollback)
+	}
+	pc, ok := cm.GetConfig().PathMatcher.Match("/users", http.MethodGet)
+	if !ok || pc.Response.StatusCode != 200 {
+		t.Errorf("users after rollback = %+v", pc)
+	}
+	if _, ok := cm.GetConfig().PathMatcher.Match("/orders", http.MethodGet); ok {
+		t.Error("orders still configured after rollback")
+	}
+
+	for target, want := range map[string]int{"/config/rollback/42": http.StatusNotFound, "/config/rollback/latest": http.StatusBadRequest} {
+		if rr := do(http.MethodPost, target, ""); rr.Code != want {
+			t.Errorf("%s = %d, want %d", target, rr.Code, want)
+		}
+	}
+}
+
+func TestConfigHistoryConcurrentChanges(t *testing.T) {
+	cm := config.NewConfigManager()
+	handler := NewConfigurationHandler(cm)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"name": "cfg-%d", "pattern": "^/cfg/%d$"}`, i, i)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/config/paths", strings.NewReader(body)))
+		}()
+	}
+	wg.Wait()
+
+	versions := cm.GetConfig().History.Versions()
+	if len(versions) != n {
+		t.Fatalf("recorded %d versions, want %d", len(versions), n)
+	}
+	for i, v := range versions {
+		if v.Version != i+1 || v.Configs != i+1 {
+			t.Errorf("version %d has %d configs, want version %d with %d", v.Version, v.Configs, i+1, i+1)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"echo-server/internal/config"
+	"echo-server/internal/har"
+	"echo-server/internal/openapi"
+	"echo-server/pkg/logger"
+)
+
+// handleImportOpenAPI converts an OpenAPI 3 document (JSON or YAML) into path
+// configs and adds them
+func (h *ConfigurationHandler) handleImportOpenAPI(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	configs, err := openapi.Import(r.Context(), data, openapi.ImportOptions{BasePath: r.URL.Query().Get("basePath")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.addImported(w, r, configs, "OpenAPI document")
+}
+
+// handleImportHAR converts the entries of a HAR file into path configs and
+// adds them
+func (h *ConfigurationHandler) handleImportHAR(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	configs, err := har.Import(data, har.ImportOptions{Host: r.URL.Query().Get("host")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.addImported(w, r, configs, "HAR file")
+}
+
+// addImported adds imported configs, replacing configs with the same names
+// so the same source can be imported again. With ?dryRun the configs are
+// only returned.
+func (h *ConfigurationHandler) addImported(w http.ResponseWriter, r *http.Request, configs []config.PathConfig, source string) {
+	status := http.StatusOK
+	if !r.URL.Query().Has("dryRun") {
+		_, err := h.updateConfigs(r, func(current []config.PathConfig) ([]config.PathConfig, error) {
+			next := current
+			for _, pc := range configs {
+				for i := range next {
+					if next[i].Name == pc.Name {
+						next = append(next[:i], next[i+1:]...)
+						break
+					}
+				}
+				next = append(next, pc)
+			}
+			return next, nil
+		})
+		if err != nil {
+			logger.Error("Failed to add imported path configs: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.Info("Imported %d path configs from %s", len(configs), source)
+		status = http.StatusCreated
+	}
+
+	if configs == nil {
+		configs = []config.PathConfig{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(configs); err != nil {
+		logger.Error("Failed to encode imported configs: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+)
+
+const importSpec = `
+openapi: 3.0.3
+info:
+  title: Orders
+  version: "1.0"
+paths:
+  /orders/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      operationId: getOrder
+      responses:
+        "200":
+          description: An order
+          content:
+            application/json:
+              example: {"id": 7, "state": "open"}
+`
+
+func TestConfigImportOpenAPI(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher()})
+	handler := NewConfigurationHandler(cm)
+
+	tests := []struct {
+		name        string
+		target      string
+		body        string
+		wantStatus  int
+		wantConfigs int
+	}{
+		{name: "dry run", target: "/config/import/openapi?dryRun", body: importSpec, wantStatus: http.StatusOK, wantConfigs: 0},
+		{name: "import", target: "/config/import/openapi", body: importSpec, wantStatus: http.StatusCreated, wantConfigs: 1},
+		{name: "import again replaces", target: "/config/import/openapi", body: importSpec, wantStatus: http.StatusCreated, wantConfigs: 1},
+		{name: "invalid document", target: "/config/import/openapi", body: "openapi: [", wantStatus: http.StatusBadRequest, wantConfigs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if got := len(cm.GetConfig().PathMatcher.GetAllConfigs()); got != tt.wantConfigs {
+				t.Errorf("loaded configs = %d, want %d", got, tt.wantConfigs)
+			}
+			if rr.Code >= 300 {
+				return
+			}
+			var configs []config.PathConfig
+			if err := json.NewDecoder(rr.Body).Decode(&configs); err != nil {
+				t.Fatal(err)
+			}
+			if len(configs) != 1 || configs[0].Name != "getOrder" || configs[0].Pattern != `^/orders/[^/]+$` {
+				t.Errorf("unexpected configs %+v", configs)
+			}
+		})
+	}
+
+	pc, ok := cm.GetConfig().PathMatcher.Match("/orders/7", http.MethodGet)
+	if !ok || pc.Response.Body != `{"id":7,"state":"open"}` {
+		t.Errorf("imported config not matched: %+v", pc)
+	}
+}
+
+func TestConfigImportHAR(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{PathMatcher: config.NewPathMatcher()})
+	handler := NewConfigurationHandler(cm)
+
+	session := `{"log": {"version": "1.2", "creator": {"name": "test", "version": "1"}, "entries": [
+		{"startedDateTime": "2024-05-01T10:00:00Z", "time": 1,
+		 "request": {"method": "GET", "url": "https://api.example.com/users/7", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+		 "response": {"status": 404, "statusText": "Not Found", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [], "content": {"size": 9, "mimeType": "text/plain", "text": "not found"}, "redirectURL": "", "headersSize": -1, "bodySize": 9},
+		 "cache": {}, "timings": {"send": 0, "wait": 1, "receive": 0}}
+	]}}`
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/config/import/har?host=api.example.com", strings.NewReader(session)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	pc, ok := cm.GetConfig().PathMatcher.Match("/users/7", http.MethodGet)
+	if !ok || pc.Response.StatusCode != 404 || pc.Response.Body != "not found" {
+		t.Errorf("imported config = %+v", pc)
+	}
+
+	// Imported configs can be addressed by name
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/config/"+pc.Name, nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("DELETE /config/%s = %d, want 204", pc.Name, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/config/import/har", strings.NewReader("{")))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid file status = %d, want 400", rr.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"echo-server/pkg/logger"
+)
+
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// LogLevelHandler reports and changes the global log level at runtime. The new
+// level is taken from a JSON body ({"level":"debug"}) or the level query
+// parameter.
+func LogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		req := LogLevelResponse{Level: r.URL.Query().Get("level")}
+		if req.Level == "" {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		level, err := logger.ParseLevel(req.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		previous := logger.GetLevel()
+		logger.SetLevel(level)
+		logger.Info("Log level changed from %s to %s", previous.Name(), level.Name())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(LogLevelResponse{Level: logger.GetLevel().Name()}); err != nil {
+		logger.Error("Failed to encode log level response: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/pkg/logger"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	previous := logger.GetLevel()
+	t.Cleanup(func() { logger.SetLevel(previous) })
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantLevel  string
+	}{
+		{"set from body", "PUT", "/admin/log-level", `{"level":"warn"}`, http.StatusOK, "warn"},
+		{"get current", "GET", "/admin/log-level", "", http.StatusOK, "warn"},
+		{"set from query", "POST", "/admin/log-level?level=debug", "", http.StatusOK, "debug"},
+		{"invalid level", "PUT", "/admin/log-level", `{"level":"loud"}`, http.StatusBadRequest, ""},
+		{"method not allowed", "DELETE", "/admin/log-level", "", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			LogLevelHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantLevel == "" {
+				return
+			}
+			var resp LogLevelResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Level != tt.wantLevel || logger.GetLevel().Name() != tt.wantLevel {
+				t.Errorf("Level = %q, want %q", resp.Level, tt.wantLevel)
+			}
+		})
+	}
+}
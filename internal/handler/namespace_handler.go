@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/config"
+	"echo-server/internal/namespace"
+	"echo-server/pkg/logger"
+)
+
+// NamespaceRequest creates a namespace. Name is generated when empty, TTL
+// removes the namespace once unused for that long, CopyPaths starts it with
+// the server's path configs instead of none.
+type NamespaceRequest struct {
+	Name      string          `json:"name,omitempty"`
+	TTL       config.Duration `json:"ttl,omitempty"`
+	CopyPaths bool            `json:"copyPaths,omitempty"`
+}
+
+// NamespaceHandler serves /namespaces: GET lists them, POST creates one,
+// GET and DELETE /namespaces/{name} describe and delete one
+type NamespaceHandler struct {
+	configManager *config.ConfigManager
+	registry      *namespace.Registry
+}
+
+func NewNamespaceHandler(cm *config.ConfigManager, registry *namespace.Registry) *NamespaceHandler {
+	return &NamespaceHandler{configManager: cm, registry: registry}
+}
+
+func (h *NamespaceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/namespaces"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		infos := []namespace.Info{}
+		for _, ns := range h.registry.List() {
+			infos = append(infos, ns.Info())
+		}
+		writeNamespaceJSON(w, http.StatusOK, infos)
+
+	case r.Method == http.MethodPost && name == "":
+		var req NamespaceRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+		ns, err := h.registry.Create(req.Name, h.configManager.GetConfig(), req.TTL.Duration, req.CopyPaths)
+		switch {
+		case errors.Is(err, namespace.ErrExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeNamespaceJSON(w, http.StatusCreated, ns.Info())
+
+	case r.Method == http.MethodGet:
+		ns, ok := h.registry.Get(name)
+		if !ok {
+			http.Error(w, "Namespace not found", http.StatusNotFound)
+			return
+		}
+		writeNamespaceJSON(w, http.StatusOK, ns.Info())
+
+	case r.Method == http.MethodDelete && name != "":
+		if !h.registry.Delete(name) {
+			http.Error(w, "Namespace not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeNamespaceJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode namespace response: %v", err)
+	}
+}
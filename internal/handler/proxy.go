@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/config"
+	"echo-server/internal/model"
+	"echo-server/internal/tracing"
+	"echo-server/pkg/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// forwardToProxy sends the request to the configured upstream and replaces the
+// echoed body with the upstream response body. The trace context is injected
+// so the upstream call joins the caller's trace.
+func (h *EchoHandler) forwardToProxy(r *http.Request, proxy *config.ProxyConfig, data *model.RequestData) error {
+	log := logger.FromContext(r.Context())
+
+	ctx, span := tracing.Tracer().Start(r.Context(), "proxy "+r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLFull(proxy.URL),
+		),
+	)
+	defer span.End()
+
+	// The incoming body was already read into data
+	proxyReq, err := http.NewRequestWithContext(ctx, r.Method, proxy.URL, strings.NewReader(data.Body))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("creating proxy request: %w", err)
+	}
+	proxyReq.Header.Set("X-Forwarded-For", r.RemoteAddr)
+	proxyReq.Header.Set("X-Forwarded-Proto", r.URL.Scheme)
+	proxyReq.Header.Set("X-Forwarded-Host", r.Host)
+	proxyReq.Header.Set("X-Forwarded-Method", r.Method)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(proxyReq.Header))
+
+	client := &http.Client{Timeout: proxy.Timeout.Duration}
+	proxyResp, err := client.Do(proxyReq)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	defer proxyResp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(proxyResp.StatusCode))
+
+	body, err := io.ReadAll(proxyResp.Body)
+	if err != nil {
+		// Keep the echoed request body, as before
+		log.Error("Failed to read proxy response body: %v", err)
+		return nil
+	}
+	data.Body = string(body)
+	log.Debug("Received proxy response: %s", data.Body)
+	return nil
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/middleware"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProxyTracePropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	const incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var upstreamTraceparent, upstreamBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+		body := make([]byte, r.ContentLength)
+		r.Body.Read(body)
+		upstreamBody = string(body)
+		w.Write([]byte(`{"upstream":true}`))
+	}))
+	defer upstream.Close()
+
+	cfg := &config.ServerConfig{
+		PathMatcher: config.NewPathMatcher(),
+	}
+	if err := cfg.PathMatcher.Add(&config.PathConfig{
+		Name:    "proxied",
+		Pattern: "^/proxied$",
+		Proxy:   &config.ProxyConfig{URL: upstream.URL},
+		// The proxied response replaces the request body in the template data
+		Response: config.ResponseConfig{Body: "template:{{.Body}}"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	handler := middleware.Tracing(middleware.RequestLogging(NewEchoHandler(cfg)))
+
+	req := httptest.NewRequest("POST", "/proxied", strings.NewReader(`{"hello":"upstream"}`))
+	req.Header.Set("traceparent", "00-"+incomingTraceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want 200", w.Code)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `{"upstream":true}` {
+		t.Errorf("Body = %q, want the upstream response", body)
+	}
+	if upstreamBody != `{"hello":"upstream"}` {
+		t.Errorf("Upstream body = %q, want the request body", upstreamBody)
+	}
+	if !strings.Contains(upstreamTraceparent, incomingTraceID) {
+		t.Errorf("Upstream traceparent = %q, want trace %s", upstreamTraceparent, incomingTraceID)
+	}
+
+	spans := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() != incomingTraceID {
+			t.Errorf("Span %s has trace %s, want %s", span.Name(), span.SpanContext().TraceID(), incomingTraceID)
+		}
+		spans[span.Name()] = true
+	}
+	for _, name := range []string{"POST proxied", "match", "proxy POST", "render"} {
+		if !spans[name] {
+			t.Errorf("Missing span %q, got %v", name, spans)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/internal/journal"
+	"echo-server/internal/model"
+	"echo-server/internal/namespace"
+)
+
+// sensitiveHeaders are masked in the journals, in addition to the headers the
+// matched config redacts
+var sensitiveHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+	"X-Api-Key", "Api-Key", "X-Auth-Token", "X-Csrf-Token",
+}
+
+// journalHeaders returns a copy of headers that is safe to keep in a journal
+func journalHeaders(headers http.Header, redact []string) http.Header {
+	return redactHeaders(headers, append(sensitiveHeaders[:len(sensitiveHeaders):len(sensitiveHeaders)], redact...))
+}
+
+// recordRequest adds a request answered by a mock and its response to the
+// request journal
+func recordRequest(r *http.Request, data *model.RequestData, meta *model.RequestMeta, start time.Time, capture *captureWriter) {
+	entry := journal.Entry{
+		Time:       start,
+		RequestID:  meta.RequestID,
+		Method:     r.Method,
+		Host:       r.Host,
+		TLS:        r.TLS != nil,
+		Path:       r.URL.Path,
+		Query:      r.URL.RawQuery,
+		Proto:      r.Proto,
+		Headers:    journalHeaders(r.Header, meta.RedactHeaders),
+		Body:       journal.Truncate(data.Body),
+		BodySize:   len(data.Body),
+		RemoteAddr: r.RemoteAddr,
+		Config:     meta.ConfigName,
+		Duration:   config.Duration{Duration: time.Since(start)},
+	}
+	if capture != nil {
+		body := capture.buf.Bytes()
+		if capture.size > len(body) {
+			body = journal.Cut(body, capture.limit)
+		}
+		entry.Response = &journal.Response{
+			Status:  capture.status,
+			Headers: journalHeaders(capture.Header(), meta.RedactHeaders),
+			Body:    string(body),
+			Size:    capture.size,
+		}
+	}
+	namespace.Requests(r.Context()).Add(entry)
+}
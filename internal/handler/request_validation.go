@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/config"
+	"echo-server/internal/model"
+	"echo-server/internal/openapi"
+	"echo-server/pkg/logger"
+)
+
+// requestValidation returns the validation applying to a request, the path
+// config's own or the server wide one
+func (h *EchoHandler) requestValidation(pathConfig *config.PathConfig, matched bool) *config.RequestValidationConfig {
+	if matched && pathConfig.RequestValidation != nil {
+		return pathConfig.RequestValidation
+	}
+	return h.config.RequestValidation
+}
+
+// checkRequest validates a request against an OpenAPI document. It returns
+// nil when the request may be answered as configured.
+func checkRequest(r *http.Request, vc *config.RequestValidationConfig, data *model.RequestData) (*openapi.ValidationReport, error) {
+	validator, err := openapi.ValidatorFor(vc)
+	if err != nil {
+		return nil, err
+	}
+	// The body was consumed when the request data was extracted
+	r.Body = io.NopCloser(strings.NewReader(data.Body))
+	report := validator.Validate(r)
+	if report == nil || (vc.AllowUndocumented && report.Undocumented()) {
+		return nil, nil
+	}
+	return report, nil
+}
+
+// answerInvalid answers requests checkRequest found to violate the OpenAPI
+// document with the configured status and the report. It returns false when
+// it wrote a response.
+func (h *EchoHandler) answerInvalid(w http.ResponseWriter, r *http.Request, vc *config.RequestValidationConfig, report *openapi.ValidationReport, err error) bool {
+	log := logger.FromContext(r.Context())
+
+	if err != nil {
+		log.Error("Failed to load OpenAPI document %s: %v", vc.Spec, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return false
+	}
+	if report == nil {
+		return true
+	}
+
+	log.Info("Request %s %s violates %s: %d problems", r.Method, r.URL.Path, vc.Spec, len(report.Violations))
+	for key, value := range vc.Headers {
+		w.Header().Set(key, value)
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(vc.ResponseStatus())
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Error("Failed to encode validation report: %v", err)
+	}
+	return false
+}
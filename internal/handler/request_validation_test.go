@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+	"echo-server/internal/openapi"
+)
+
+const petsSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+      responses:
+        "201":
+          description: Created
+`
+
+func TestRequestValidation(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(spec, []byte(petsSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := config.NewPathMatcher()
+	for _, pc := range []config.PathConfig{
+		{Name: "pets", Pattern: "^/pets$", Response: config.ResponseConfig{StatusCode: 201, Body: `{"id":1}`}},
+		{
+			Name:     "teapot",
+			Pattern:  "^/teapot$",
+			Response: config.ResponseConfig{StatusCode: 418, Body: "short and stout"},
+			RequestValidation: &config.RequestValidationConfig{
+				Spec:       spec,
+				StatusCode: http.StatusUnprocessableEntity,
+				Headers:    map[string]string{"X-Contract": "violated"},
+			},
+		},
+		{Name: "legacy", Pattern: "^/legacy$", Response: config.ResponseConfig{StatusCode: 200, Body: "ok"}},
+	} {
+		if err := pm.Add(&pc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name              string
+		allowUndocumented bool
+		method            string
+		path              string
+		body              string
+		wantStatus        int
+		wantHeader        string
+		wantViolations    int
+	}{
+		{name: "valid request gets the configured response", method: "POST", path: "/pets", body: `{"name":"rex"}`, wantStatus: 201},
+		{name: "invalid body", method: "POST", path: "/pets", body: `{"age":3}`, wantStatus: 400, wantViolations: 1},
+		{name: "path config overrides status and headers", method: "GET", path: "/teapot", wantStatus: 422, wantHeader: "violated", wantViolations: 1},
+		{name: "undocumented request", method: "GET", path: "/legacy", wantStatus: 400, wantViolations: 1},
+		{name: "undocumented request allowed", allowUndocumented: true, method: "GET", path: "/legacy", wantStatus: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewEchoHandler(&config.ServerConfig{
+				PathMatcher:       pm,
+				RequestValidation: &config.RequestValidationConfig{Spec: spec, AllowUndocumented: tt.allowUndocumented},
+			})
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if got := rr.Header().Get("X-Contract"); got != tt.wantHeader {
+				t.Errorf("X-Contract = %q, want %q", got, tt.wantHeader)
+			}
+			if tt.wantViolations == 0 {
+				return
+			}
+			var report openapi.ValidationReport
+			if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Violations) != tt.wantViolations || report.Path != tt.path {
+				t.Errorf("unexpected report %+v", report)
+			}
+		})
+	}
+}
+
+func TestRequestValidationKeepsUses(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(spec, []byte(petsSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := config.NewPathMatcher()
+	if err := pm.Add(&config.PathConfig{
+		Name:              "pets-once",
+		Pattern:           "^/pets$",
+		MaxUses:           1,
+		Response:          config.ResponseConfig{StatusCode: 201, Body: `{"id":1}`},
+		RequestValidation: &config.RequestValidationConfig{Spec: spec},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewEchoHandler(&config.ServerConfig{PathMatcher: pm})
+	c := counter.GetGlobalCounter()
+	c.ResetConfig("pets-once")
+
+	// Rejected requests neither use up the config nor count as its calls
+	for _, tt := range []struct {
+		body       string
+		wantStatus int
+		wantCount  uint64
+	}{
+		{body: `{"age":3}`, wantStatus: 400, wantCount: 0},
+		{body: `{"name":"rex"}`, wantStatus: 201, wantCount: 1},
+		{body: `{"name":"rex"}`, wantStatus: 200, wantCount: 1},
+	} {
+		req := httptest.NewRequest("POST", "/pets", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tt.wantStatus {
+			t.Fatalf("POST %s: status = %d, want %d", tt.body, rr.Code, tt.wantStatus)
+		}
+		if got := c.GetConfigCount("pets-once"); got != tt.wantCount {
+			t.Errorf("POST %s: config count = %d, want %d", tt.body, got, tt.wantCount)
+		}
+	}
+}
+
+func TestExplainRequestValidation(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(spec, []byte(petsSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{
+		PathMatcher:       config.NewPathMatcher(),
+		RequestValidation: &config.RequestValidationConfig{Spec: spec},
+	})
+
+	req := httptest.NewRequest("POST", "/config/explain", strings.NewReader(
+		`{"method": "POST", "path": "/pets", "headers": {"Content-Type": "application/json"}, "body": "{}"}`))
+	rr := httptest.NewRecorder()
+	NewConfigurationHandler(cm).ServeHTTP(rr, req)
+
+	var result ExplainResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Response.Source != "requestValidation" || result.Response.StatusCode != 400 {
+		t.Errorf("response = %+v, want the validation response", result.Response)
+	}
+	if result.Validation == nil || result.Validation.Operation != "createPet" {
+		t.Errorf("validation = %+v, want a report for createPet", result.Validation)
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/har"
+	"echo-server/internal/journal"
+	"echo-server/internal/namespace"
+	"echo-server/pkg/logger"
+)
+
+// RequestListResponse lists recent requests answered by the mocks
+type RequestListResponse struct {
+	Total    uint64          `json:"total"`
+	Requests []journal.Entry `json:"requests"`
+}
+
+// RequestsHandler lists (GET) or clears (DELETE) the request journal.
+// GET /requests/har downloads it as a HAR file.
+func RequestsHandler(w http.ResponseWriter, r *http.Request) {
+	j := namespace.Requests(r.Context())
+	asHAR := strings.HasSuffix(r.URL.Path, "/har")
+
+	switch {
+	case r.Method == http.MethodGet && asHAR:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="echo-server.har"`)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(har.Export(j.Entries())); err != nil {
+			logger.Error("Failed to encode HAR export: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+
+	case r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(RequestListResponse{Total: j.Total(), Requests: j.Entries()}); err != nil {
+			logger.Error("Failed to encode requests: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+
+	case r.Method == http.MethodDelete && !asHAR:
+		j.Clear()
+		logger.Info("Cleared request journal")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/har"
+	"echo-server/internal/journal"
+)
+
+func TestRequestsHandler(t *testing.T) {
+	journal.Requests().Clear()
+	t.Cleanup(journal.Requests().Clear)
+
+	pm := config.NewPathMatcher()
+	if err := pm.Add(&config.PathConfig{
+		Name:     "users",
+		Pattern:  "^/users$",
+		Response: config.ResponseConfig{StatusCode: 201, Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"id":7}`},
+		Logging:  &config.LoggingConfig{RedactHeaders: []string{"X-Session"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	echo := NewEchoHandler(&config.ServerConfig{PathMatcher: pm})
+	for _, path := range []string{"/users", "/other"} {
+		req := httptest.NewRequest("POST", path+"?q=1", strings.NewReader(`{"name":"Ada"}`))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Session", "secret")
+		echo.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rr := httptest.NewRecorder()
+	RequestsHandler(rr, httptest.NewRequest("GET", "/requests", nil))
+	var list RequestListResponse
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 2 || len(list.Requests) != 2 {
+		t.Fatalf("requests = %+v, want 2", list)
+	}
+	first := list.Requests[0]
+	if first.Config != "users" || first.Body != `{"name":"Ada"}` || first.Query != "q=1" {
+		t.Errorf("entry = %+v", first)
+	}
+	if first.Response == nil || first.Response.Status != 201 || strings.TrimSpace(first.Response.Body) != `{"id":7}` {
+		t.Errorf("response = %+v", first.Response)
+	}
+	// Credentials never reach the journal, the route's redacted headers neither
+	if got := first.Headers.Get("Authorization") + first.Headers.Get("X-Session"); got != "[REDACTED][REDACTED]" {
+		t.Errorf("headers = %v", first.Headers)
+	}
+	if got := list.Requests[1].Headers.Get("Authorization"); got != "[REDACTED]" {
+		t.Errorf("unmatched request headers = %v", list.Requests[1].Headers)
+	}
+	if list.Requests[1].Config != "" || list.Requests[1].Response.Status != http.StatusOK {
+		t.Errorf("unmatched entry = %+v", list.Requests[1])
+	}
+
+	rr = httptest.NewRecorder()
+	RequestsHandler(rr, httptest.NewRequest("GET", "/requests/har", nil))
+	if got := rr.Header().Get("Content-Disposition"); !strings.Contains(got, "echo-server.har") {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	exported, err := har.Parse(rr.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.Log.Entries) != 2 || exported.Log.Entries[0].Request.URL != "http://example.com/users?q=1" {
+		t.Errorf("HAR entries = %+v", exported.Log.Entries)
+	}
+
+	rr = httptest.NewRecorder()
+	RequestsHandler(rr, httptest.NewRequest("DELETE", "/requests", nil))
+	if rr.Code != http.StatusNoContent || journal.Requests().Total() != 0 {
+		t.Errorf("DELETE status = %d, total = %d", rr.Code, journal.Requests().Total())
+	}
+}
@@ -1,8 +1,23 @@
+// Admin endpoints live next to the UI, below an optional admin prefix
+const adminBase = window.location.pathname.replace(/\/ui(\/.*)?$/, '');
+
+// Example requests generated by the server, keyed by config name and pattern
+let configExamples = new Map();
+
+function exampleKey(config) {
+    return `${config.name}\n${config.pattern}`;
+}
+
 // Load configurations
 async function fetchConfigs() {
     try {
-        const response = await fetch('/config');
-        const configs = await response.json();
+        const [configsResponse, examplesResponse] = await Promise.all([
+            fetch(`${adminBase}/config`),
+            fetch(`${adminBase}/config/examples`)
+        ]);
+        const configs = await configsResponse.json();
+        const examples = await examplesResponse.json();
+        configExamples = new Map(examples.map(example => [exampleKey(example), example]));
         updateConfigList(configs);
         return configs;
     } catch (error) {
@@ -10,7 +25,15 @@ async function fetchConfigs() {
     }
 }
 
+function escapeHTML(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
 function renderConfig(config) {
+    const example = configExamples.get(exampleKey(config));
+
     const item = document.createElement('div');
     item.className = 'config-item collapsed';
     
@@ -30,7 +53,15 @@ function renderConfig(config) {
 
     const content = document.createElement('div');
     content.className = 'config-content';
-    content.innerHTML = `<pre>${JSON.stringify(config, null, 2)}</pre>`;
+    const curls = example && example.requests.length > 0
+        ? example.requests.map(req => escapeHTML(req.curl)).join('\n')
+        : 'No example path could be generated for this pattern';
+    content.innerHTML = `
+        <h4>Example requests</h4>
+        <pre class="curl">${curls}</pre>
+        <h4>Configuration</h4>
+        <pre>${escapeHTML(JSON.stringify(config, null, 2))}</pre>
+    `;
 
     item.appendChild(header);
     item.appendChild(content);
@@ -46,8 +77,9 @@ function renderConfig(config) {
         // Switch to tester tab
         switchToTab('tester');
         // Populate form with test values
-        document.getElementById('method').value = config.methods[0] || 'GET';
-        document.getElementById('path').value = config.pattern.replace('^', '').replace('$', '');
+        const request = example && example.requests[0];
+        document.getElementById('method').value = request ? request.method : 'GET';
+        document.getElementById('path').value = example && example.path ? example.path : '/';
         document.getElementById('requestBody').value = '';
     });
 
@@ -94,16 +126,16 @@ function updateConfigList(configs) {
 
 // Load counters
 function loadCounters() {
-    fetch('/counter')
+    fetch(`${adminBase}/counter`)
         .then(response => response.json())
         .then(data => {
             const counterList = document.getElementById('counterList');
             counterList.innerHTML = `
                     <div class="counter-item">
-                        <h3>Global Counter: ${data.globalCount}</h3>
+                        <h3>Global Counter: ${data.global}</h3>
                     </div>
                 `;
-            Object.entries(data.pathCounts).forEach(([path, count]) => {
+            Object.entries(data.paths).forEach(([path, count]) => {
                 const div = document.createElement('div');
                 div.className = 'counter-item';
                 div.innerHTML = `
@@ -157,7 +189,7 @@ document.addEventListener('DOMContentLoaded', () => {
     // Counter management
     document.getElementById('refreshCounters').addEventListener('click', loadCounters);
     document.getElementById('resetCounters').addEventListener('click', () => {
-        fetch('/counter', { method: 'DELETE' })
+        fetch(`${adminBase}/counter`, { method: 'DELETE' })
             .then(() => loadCounters());
     });
 
@@ -188,12 +220,12 @@ document.addEventListener('DOMContentLoaded', () => {
 
 // Counter reset function
 function resetPathCounter(path) {
-    fetch(`/counter/${encodeURIComponent(path)}`, { method: 'DELETE' })
+    fetch(`${adminBase}/counter${encodeURI(path)}`, { method: 'DELETE' })
         .then(() => loadCounters());
 }
 
 // Config deletion function
 function deleteConfig(pattern) {
-    fetch(`/config/${encodeURIComponent(pattern)}`, { method: 'DELETE' })
+    fetch(`${adminBase}/config/${encodeURIComponent(pattern)}`, { method: 'DELETE' })
         .then(() => fetchConfigs());
 }
\ No newline at end of file
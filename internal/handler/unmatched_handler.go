@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"echo-server/internal/journal"
+	"echo-server/internal/namespace"
+	"echo-server/pkg/logger"
+)
+
+// UnmatchedListResponse lists recent requests no path config matched
+type UnmatchedListResponse struct {
+	Total    uint64          `json:"total"`
+	Requests []journal.Entry `json:"requests"`
+}
+
+// UnmatchedHandler lists (GET) or clears (DELETE) the unmatched requests. The
+// closest configs of requests answered leniently are those loaded when the
+// list is read.
+func UnmatchedHandler(w http.ResponseWriter, r *http.Request) {
+	j := namespace.Unmatched(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		entries := j.Entries()
+		for i := range entries {
+			if entries[i].Closest == nil && entries[i].Nearest != nil {
+				entries[i].Closest = entries[i].Nearest()
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(UnmatchedListResponse{Total: j.Total(), Requests: entries}); err != nil {
+			logger.Error("Failed to encode unmatched requests: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+
+	case http.MethodDelete:
+		j.Clear()
+		logger.Info("Cleared unmatched requests")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/internal/model"
+	"echo-server/pkg/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	// The echo server is a test tool, accept connections from any origin
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// WebSocketMessageData is the data available to templates in WebSocket replies
+// and periodic messages
+type WebSocketMessageData struct {
+	Message string             `json:"message"`
+	JSON    interface{}        `json:"json,omitempty"`
+	Count   int                `json:"count"`
+	Request *model.RequestData `json:"request"`
+}
+
+// wsConn serialises writes, gorilla/websocket allows only one concurrent writer
+type wsConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (c *wsConn) write(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func (h *EchoHandler) serveWebSocket(w http.ResponseWriter, r *http.Request, pathConfig *config.PathConfig, data *model.RequestData) {
+	log := logger.FromContext(r.Context())
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		log.Error("Failed to upgrade WebSocket connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	ws := pathConfig.WebSocket
+	c := &wsConn{conn: conn}
+	log.Info("WebSocket connection opened for %s (mode: %s)", r.URL.Path, wsMode(ws))
+
+	done := make(chan struct{})
+	defer close(done)
+
+	for _, timer := range ws.Periodic {
+		go h.runWebSocketTimer(log, c, timer, data, done)
+	}
+
+	count := 0
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Warn("WebSocket read error on %s: %v", r.URL.Path, err)
+			}
+			log.Info("WebSocket connection closed for %s after %d messages", r.URL.Path, count)
+			return
+		}
+		count++
+		log.Debug("WebSocket message #%d on %s: %s", count, r.URL.Path, message)
+
+		if wsMode(ws) == config.WebSocketModeEcho {
+			if err := c.write(messageType, message); err != nil {
+				log.Error("Failed to echo WebSocket message: %v", err)
+				return
+			}
+			continue
+		}
+
+		rule, ok := ws.MatchRule(message)
+		if !ok {
+			log.Debug("No WebSocket rule matched message on %s", r.URL.Path)
+			continue
+		}
+		if rule.Delay.Duration > 0 {
+			time.Sleep(rule.Delay.Duration)
+		}
+
+		reply, err := renderWebSocketMessage(rule.Reply, newWebSocketMessageData(message, count, data))
+		if err != nil {
+			log.Error("Failed to render WebSocket reply: %v", err)
+			continue
+		}
+		if err := c.write(websocket.TextMessage, reply); err != nil {
+			log.Error("Failed to send WebSocket reply: %v", err)
+			return
+		}
+	}
+}
+
+func (h *EchoHandler) runWebSocketTimer(log *logger.Logger, c *wsConn, timer config.WebSocketTimer, data *model.RequestData, done <-chan struct{}) {
+	ticker := time.NewTicker(timer.Interval.Duration)
+	defer ticker.Stop()
+
+	for sent := 0; timer.Count == 0 || sent < timer.Count; {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			sent++
+			message, err := renderWebSocketMessage(timer.Message, &WebSocketMessageData{Count: sent, Request: data})
+			if err != nil {
+				log.Error("Failed to render periodic WebSocket message: %v", err)
+				continue
+			}
+			if err := c.write(websocket.TextMessage, message); err != nil {
+				log.Debug("Stopping periodic WebSocket message: %v", err)
+				return
+			}
+		}
+	}
+}
+
+func newWebSocketMessageData(message []byte, count int, data *model.RequestData) *WebSocketMessageData {
+	msgData := &WebSocketMessageData{
+		Message: string(message),
+		Count:   count,
+		Request: data,
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(message, &parsed); err == nil {
+		msgData.JSON = parsed
+	}
+	return msgData
+}
+
+// renderWebSocketMessage processes "template:" prefixed messages the same way
+// response bodies are processed, other messages are sent verbatim
+func renderWebSocketMessage(message string, data *WebSocketMessageData) ([]byte, error) {
+	if !strings.HasPrefix(message, "template:") {
+		return []byte(message), nil
+	}
+
+	tmpl, err := template.New("websocket").Parse(strings.TrimPrefix(message, "template:"))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func wsMode(ws *config.WebSocketConfig) string {
+	if ws.Mode == "" {
+		return config.WebSocketModeEcho
+	}
+	return ws.Mode
+}
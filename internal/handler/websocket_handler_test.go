@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/internal/middleware"
+
+	"github.com/gorilla/websocket"
+)
+
+func newWebSocketTestServer(t *testing.T, pathConfig config.PathConfig) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.ServerConfig{
+		PathMatcher: config.NewPathMatcher(),
+	}
+	if err := cfg.PathMatcher.Add(&pathConfig); err != nil {
+		t.Fatalf("Failed to add path config: %v", err)
+	}
+
+	srv := httptest.NewServer(middleware.RequestLogging(NewEchoHandler(cfg)))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server, path string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + path
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial %s: %v", url, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return conn
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	srv := newWebSocketTestServer(t, config.PathConfig{
+		Pattern:   "^/ws/echo$",
+		WebSocket: &config.WebSocketConfig{},
+	})
+	conn := dialWebSocket(t, srv, "/ws/echo")
+
+	for _, msg := range []string{"hello", `{"type":"ping"}`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+		_, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+		if string(got) != msg {
+			t.Errorf("Echo = %q, want %q", got, msg)
+		}
+	}
+}
+
+func TestWebSocketScript(t *testing.T) {
+	srv := newWebSocketTestServer(t, config.PathConfig{
+		Pattern: "^/ws/chat$",
+		WebSocket: &config.WebSocketConfig{
+			Mode: config.WebSocketModeScript,
+			Rules: []config.WebSocketRule{
+				{Match: "^ping$", JSONField: "type", Reply: `{"type":"pong"}`},
+				{Match: "^hello", Reply: "template:hi {{.Message}} #{{.Count}}"},
+			},
+		},
+	})
+	conn := dialWebSocket(t, srv, "/ws/chat")
+
+	tests := []struct {
+		send string
+		want string
+	}{
+		{`{"type":"ping","id":1}`, `{"type":"pong"}`},
+		{"hello world", "hi hello world #2"},
+		// Unmatched message gets no reply, the next reply proves ordering
+		{"ignored", ""},
+		{"hello again", "hi hello again #4"},
+	}
+
+	for _, tt := range tests {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(tt.send)); err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+		if tt.want == "" {
+			continue
+		}
+		_, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Reply to %q = %q, want %q", tt.send, got, tt.want)
+		}
+	}
+}
+
+func TestWebSocketPeriodic(t *testing.T) {
+	srv := newWebSocketTestServer(t, config.PathConfig{
+		Pattern: "^/ws/updates$",
+		WebSocket: &config.WebSocketConfig{
+			Mode: config.WebSocketModeScript,
+			Periodic: []config.WebSocketTimer{
+				{
+					Interval: config.Duration{Duration: 10 * time.Millisecond},
+					Message:  `template:{"tick":{{.Count}}}`,
+					Count:    2,
+				},
+			},
+		},
+	})
+	conn := dialWebSocket(t, srv, "/ws/updates")
+
+	for _, want := range []string{`{"tick":1}`, `{"tick":2}`} {
+		_, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("Periodic message = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestWebSocketInvalidRule(t *testing.T) {
+	pm := config.NewPathMatcher()
+	err := pm.Add(&config.PathConfig{
+		Pattern: "^/ws$",
+		WebSocket: &config.WebSocketConfig{
+			Mode:  config.WebSocketModeScript,
+			Rules: []config.WebSocketRule{{Match: "("}},
+		},
+	})
+	if err == nil {
+		t.Error("Expected invalid rule regex to be rejected")
+	}
+}
@@ -0,0 +1,142 @@
+package har
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"unicode/utf8"
+
+	"echo-server/internal/journal"
+)
+
+// Version is the HAR format version written by Export
+const Version = "1.2"
+
+// Export converts journal entries into a HAR document
+func Export(entries []journal.Entry) *HAR {
+	h := &HAR{Log: Log{
+		Version: Version,
+		Creator: Creator{Name: "echo-server", Version: buildVersion()},
+		Entries: make([]Entry, 0, len(entries)),
+	}}
+	for _, e := range entries {
+		h.Log.Entries = append(h.Log.Entries, exportEntry(e))
+	}
+	return h
+}
+
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return ""
+}
+
+func exportEntry(e journal.Entry) Entry {
+	scheme := "http"
+	if e.TLS {
+		scheme = "https"
+	}
+	u := url.URL{Scheme: scheme, Host: e.Host, Path: e.Path, RawQuery: e.Query}
+	millis := float64(e.Duration.Microseconds()) / 1000
+
+	entry := Entry{
+		StartedDateTime: e.Time,
+		Time:            millis,
+		Request: Request{
+			Method:      e.Method,
+			URL:         u.String(),
+			HTTPVersion: e.Proto,
+			Cookies:     requestCookies(e.Headers),
+			Headers:     nameValues(e.Headers),
+			QueryString: queryString(e.Query),
+			HeadersSize: -1,
+			BodySize:    e.BodySize,
+		},
+		Timings: Timings{Wait: millis},
+		Comment: e.Config,
+	}
+	if e.Body != "" {
+		entry.Request.PostData = &PostData{MimeType: e.Headers.Get("Content-Type"), Text: e.Body}
+	}
+
+	if resp := e.Response; resp != nil {
+		entry.Response = Response{
+			Status:      resp.Status,
+			StatusText:  http.StatusText(resp.Status),
+			HTTPVersion: e.Proto,
+			Cookies:     responseCookies(resp.Headers),
+			Headers:     nameValues(resp.Headers),
+			Content:     Content{Size: resp.Size, MimeType: resp.Headers.Get("Content-Type"), Text: resp.Body},
+			HeadersSize: -1,
+			BodySize:    resp.Size,
+		}
+		if !utf8.ValidString(resp.Body) {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString([]byte(resp.Body))
+			entry.Response.Content.Encoding = "base64"
+		}
+	} else {
+		// WebSocket upgrades hand the connection over without a response
+		entry.Response = Response{
+			Status:      http.StatusSwitchingProtocols,
+			StatusText:  http.StatusText(http.StatusSwitchingProtocols),
+			HTTPVersion: e.Proto,
+			Cookies:     []Cookie{},
+			Headers:     []NameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	}
+	return entry
+}
+
+// nameValues lists headers sorted by name
+func nameValues(headers http.Header) []NameValue {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := []NameValue{}
+	for _, name := range names {
+		for _, value := range headers[name] {
+			result = append(result, NameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func queryString(rawQuery string) []NameValue {
+	result := []NameValue{}
+	values, _ := url.ParseQuery(rawQuery)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range values[name] {
+			result = append(result, NameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func requestCookies(headers http.Header) []Cookie {
+	result := []Cookie{}
+	for _, c := range (&http.Request{Header: headers}).Cookies() {
+		result = append(result, Cookie{Name: c.Name, Value: c.Value})
+	}
+	return result
+}
+
+func responseCookies(headers http.Header) []Cookie {
+	result := []Cookie{}
+	for _, c := range (&http.Response{Header: headers}).Cookies() {
+		result = append(result, Cookie{Name: c.Name, Value: c.Value})
+	}
+	return result
+}
@@ -0,0 +1,226 @@
+package har
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/internal/journal"
+)
+
+const session = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "Firefox", "version": "128.0"},
+    "entries": [
+      {
+        "startedDateTime": "2024-05-01T10:00:00.000+02:00",
+        "time": 42.5,
+        "request": {"method": "get", "url": "https://api.example.com/users/7?expand=1", "httpVersion": "HTTP/2", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+        "response": {
+          "status": 200, "statusText": "OK", "httpVersion": "HTTP/2", "cookies": [], "redirectURL": "", "headersSize": -1, "bodySize": 27,
+          "headers": [
+            {"name": ":status", "value": "200"},
+            {"name": "content-type", "value": "application/json"},
+            {"name": "content-length", "value": "27"},
+            {"name": "cache-control", "value": "no-cache"},
+            {"name": "vary", "value": "Accept"},
+            {"name": "vary", "value": "Origin"}
+          ],
+          "content": {"size": 27, "mimeType": "application/json", "text": "{\"id\":7,\"name\":\"Ada\"}"}
+        },
+        "cache": {},
+        "timings": {"send": 0, "wait": 40, "receive": 2.5}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:01.000+02:00",
+        "time": 10,
+        "request": {"method": "GET", "url": "https://api.example.com/users/7?expand=0", "httpVersion": "HTTP/2", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+        "response": {"status": 500, "statusText": "", "httpVersion": "HTTP/2", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": ""}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+        "cache": {},
+        "timings": {"send": 0, "wait": 10, "receive": 0}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:02.000+02:00",
+        "time": 5,
+        "request": {"method": "POST", "url": "https://api.example.com/logo", "httpVersion": "HTTP/2", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+        "response": {"status": 201, "statusText": "Created", "httpVersion": "HTTP/2", "headers": [], "cookies": [], "content": {"size": 3, "mimeType": "image/png", "text": "iVBO", "encoding": "base64"}, "redirectURL": "", "headersSize": -1, "bodySize": 3},
+        "cache": {},
+        "timings": {"send": 0, "wait": 5, "receive": 0}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:03.000+02:00",
+        "time": 0,
+        "request": {"method": "GET", "url": "https://tracker.example.net/pixel", "httpVersion": "HTTP/2", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+        "response": {"status": 204, "statusText": "No Content", "httpVersion": "HTTP/2", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": ""}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+        "cache": {},
+        "timings": {"send": 0, "wait": 0, "receive": 0}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:04.000+02:00",
+        "time": 0,
+        "request": {"method": "GET", "url": "https://api.example.com/blocked", "httpVersion": "", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": 0},
+        "response": {"status": 0, "statusText": "", "httpVersion": "", "headers": [], "cookies": [], "content": {"size": 0, "mimeType": ""}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+        "cache": {},
+        "timings": {"send": 0, "wait": 0, "receive": 0}
+      }
+    ]
+  }
+}`
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ImportOptions
+		want []config.PathConfig
+	}{
+		{
+			name: "all hosts",
+			want: []config.PathConfig{
+				{
+					Name:    "get-users-7",
+					Pattern: `^/users/7$`,
+					Methods: []string{"GET"},
+					Response: config.ResponseConfig{
+						StatusCode: 200,
+						Headers:    map[string]string{"Content-Type": "application/json", "Cache-Control": "no-cache", "Vary": "Accept, Origin"},
+						Body:       `{"id":7,"name":"Ada"}`,
+					},
+				},
+				{
+					Name:    "post-logo",
+					Pattern: `^/logo$`,
+					Methods: []string{"POST"},
+					Response: config.ResponseConfig{
+						StatusCode: 201,
+						Headers:    map[string]string{"Content-Type": "image/png"},
+						Body:       "\x89PN",
+					},
+				},
+				{
+					Name:     "get-pixel",
+					Pattern:  `^/pixel$`,
+					Methods:  []string{"GET"},
+					Response: config.ResponseConfig{StatusCode: 204, Headers: map[string]string{}},
+				},
+			},
+		},
+		{
+			name: "one host",
+			opts: ImportOptions{Host: "tracker.example.net"},
+			want: []config.PathConfig{
+				{
+					Name:     "get-pixel",
+					Pattern:  `^/pixel$`,
+					Methods:  []string{"GET"},
+					Response: config.ResponseConfig{StatusCode: 204, Headers: map[string]string{}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := Import([]byte(session), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(configs, tt.want) {
+				t.Errorf("configs = %+v\nwant %+v", configs, tt.want)
+			}
+		})
+	}
+
+	if _, err := Import([]byte("not json"), ImportOptions{}); err == nil {
+		t.Error("expected an error for an invalid file")
+	}
+}
+
+func TestExport(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	entries := []journal.Entry{
+		{
+			Time:     start,
+			Method:   "POST",
+			Host:     "localhost:8080",
+			Path:     "/users",
+			Query:    "b=2&a=1",
+			Proto:    "HTTP/1.1",
+			Headers:  http.Header{"Content-Type": {"application/json"}, "Cookie": {"session=abc"}},
+			Body:     `{"name":"Ada"}`,
+			BodySize: 14,
+			Config:   "users",
+			Duration: config.Duration{Duration: 1500 * time.Microsecond},
+			Response: &journal.Response{
+				Status:  201,
+				Headers: http.Header{"Content-Type": {"application/json"}, "Set-Cookie": {"id=7; Path=/"}},
+				Body:    `{"id":7}`,
+				Size:    8,
+			},
+		},
+		{
+			Time:     start,
+			Method:   "GET",
+			Host:     "localhost:8443",
+			TLS:      true,
+			Path:     "/image",
+			Proto:    "HTTP/2.0",
+			Response: &journal.Response{Status: 200, Body: "\xff\xd8", Size: 2},
+		},
+	}
+
+	// Round trip through JSON like a written file
+	data, err := json.Marshal(Export(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Log.Version != Version || h.Log.Creator.Name != "echo-server" || len(h.Log.Entries) != 2 {
+		t.Fatalf("unexpected log %+v", h.Log)
+	}
+
+	first := h.Log.Entries[0]
+	if first.Request.URL != "http://localhost:8080/users?b=2&a=1" {
+		t.Errorf("url = %q", first.Request.URL)
+	}
+	if want := []NameValue{{"a", "1"}, {"b", "2"}}; !reflect.DeepEqual(first.Request.QueryString, want) {
+		t.Errorf("queryString = %+v, want %+v", first.Request.QueryString, want)
+	}
+	if want := []Cookie{{"session", "abc"}}; !reflect.DeepEqual(first.Request.Cookies, want) {
+		t.Errorf("request cookies = %+v, want %+v", first.Request.Cookies, want)
+	}
+	if first.Request.PostData == nil || first.Request.PostData.Text != `{"name":"Ada"}` || first.Request.PostData.MimeType != "application/json" {
+		t.Errorf("postData = %+v", first.Request.PostData)
+	}
+	if first.Response.Status != 201 || first.Response.StatusText != "Created" || first.Response.Content.Text != `{"id":7}` {
+		t.Errorf("response = %+v", first.Response)
+	}
+	if want := []Cookie{{"id", "7"}}; !reflect.DeepEqual(first.Response.Cookies, want) {
+		t.Errorf("response cookies = %+v, want %+v", first.Response.Cookies, want)
+	}
+	if first.Time != 1.5 || first.Comment != "users" {
+		t.Errorf("time = %v, comment = %q", first.Time, first.Comment)
+	}
+
+	second := h.Log.Entries[1]
+	if second.Request.URL != "https://localhost:8443/image" {
+		t.Errorf("url = %q", second.Request.URL)
+	}
+	if second.Response.Content.Encoding != "base64" || second.Response.Content.Text != "/9g=" {
+		t.Errorf("binary content = %+v", second.Response.Content)
+	}
+
+	// An exported session can be imported again
+	configs, err := Import(data, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 || configs[0].Response.Body != `{"id":7}` || configs[1].Response.Body != "\xff\xd8" {
+		t.Errorf("re-imported configs = %+v", configs)
+	}
+}
@@ -0,0 +1,115 @@
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"echo-server/internal/config"
+)
+
+// ImportOptions tune how a HAR file is converted
+type ImportOptions struct {
+	// Host keeps only entries sent to this host, all entries when empty
+	Host string
+}
+
+// skippedHeaders describe the recorded transfer rather than the response and
+// are set by the server itself
+var skippedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Date":              true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+}
+
+// Import creates a path config for every distinct method and path in a HAR
+// file, using the first recorded response. Entries without a response, such
+// as blocked or aborted requests, are skipped.
+func Import(data []byte, opts ImportOptions) ([]config.PathConfig, error) {
+	h, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []config.PathConfig
+	seen := make(map[string]bool)
+	for i, entry := range h.Log.Entries {
+		if entry.Response.Status == 0 {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		if opts.Host != "" && !strings.EqualFold(u.Hostname(), opts.Host) && !strings.EqualFold(u.Host, opts.Host) {
+			continue
+		}
+
+		method := strings.ToUpper(entry.Request.Method)
+		path := u.Path
+		if path == "" {
+			path = "/"
+		}
+		key := method + " " + path
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		pc, err := entryConfig(config.GeneratedName(method, path), method, path, &entry.Response)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		configs = append(configs, pc)
+	}
+	return configs, nil
+}
+
+func entryConfig(name, method, path string, resp *Response) (config.PathConfig, error) {
+	pc := config.PathConfig{
+		Name:    name,
+		Pattern: "^" + regexp.QuoteMeta(path) + "$",
+		Methods: []string{method},
+		Response: config.ResponseConfig{
+			StatusCode: resp.Status,
+			Headers:    map[string]string{},
+		},
+	}
+
+	for _, header := range resp.Headers {
+		key := http.CanonicalHeaderKey(header.Name)
+		// HTTP/2 pseudo headers such as :status are not real headers
+		if strings.HasPrefix(key, ":") || skippedHeaders[key] {
+			continue
+		}
+		if existing, ok := pc.Response.Headers[key]; ok {
+			if key == "Set-Cookie" {
+				// Only one value per header can be configured
+				continue
+			}
+			pc.Response.Headers[key] = existing + ", " + header.Value
+			continue
+		}
+		pc.Response.Headers[key] = header.Value
+	}
+	if _, ok := pc.Response.Headers["Content-Type"]; !ok && resp.Content.MimeType != "" {
+		pc.Response.Headers["Content-Type"] = resp.Content.MimeType
+	}
+
+	body := resp.Content.Text
+	if resp.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return pc, fmt.Errorf("decoding response body: %w", err)
+		}
+		body = string(decoded)
+	}
+	pc.Response.Body = body
+	return pc, nil
+}
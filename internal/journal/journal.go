@@ -0,0 +1,152 @@
+package journal
+
+import (
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"echo-server/internal/config"
+)
+
+// DefaultCapacity is the number of entries kept by the global journals
+const DefaultCapacity = 500
+
+// MaxBodyBytes limits the request and response bodies kept per entry
+const MaxBodyBytes = 16 << 10
+
+// Entry is a request recorded in a journal
+type Entry struct {
+	Time       time.Time         `json:"time"`
+	RequestID  string            `json:"requestId,omitempty"`
+	Method     string            `json:"method"`
+	Host       string            `json:"host,omitempty"`
+	TLS        bool              `json:"tls,omitempty"`
+	Path       string            `json:"path"`
+	Query      string            `json:"query,omitempty"`
+	Proto      string            `json:"proto,omitempty"`
+	Headers    http.Header       `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	BodySize   int               `json:"bodySize,omitempty"`
+	RemoteAddr string            `json:"remoteAddr,omitempty"`
+	Config     string            `json:"config,omitempty"`
+	Closest    []config.NearMiss `json:"closest,omitempty"`
+	Duration   config.Duration   `json:"duration"`
+	Response   *Response         `json:"response,omitempty"`
+	// Nearest computes Closest when the entry is listed, so requests
+	// nobody looks at do not pay for it
+	Nearest func() []config.NearMiss `json:"-"`
+}
+
+// Response is the response recorded for a request. Body holds at most
+// MaxBodyBytes of the Size bytes sent.
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	Size    int         `json:"size"`
+}
+
+// Truncate returns body cut to MaxBodyBytes without splitting a UTF-8
+// encoded character
+func Truncate(body string) string {
+	if len(body) <= MaxBodyBytes {
+		return body
+	}
+	return string(Cut([]byte(body[:MaxBodyBytes]), MaxBodyBytes))
+}
+
+// Cut returns body cut to limit bytes. A body of limit bytes or more also
+// loses a character its end splits, which covers buffers that stopped
+// capturing at limit.
+func Cut(body []byte, limit int) []byte {
+	if len(body) < limit {
+		return body
+	}
+	body = body[:limit]
+	for i := len(body) - 1; i >= 0 && i >= len(body)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if !utf8.FullRune(body[i:]) {
+				body = body[:i]
+			}
+			break
+		}
+	}
+	return body
+}
+
+// Journal keeps the most recent entries up to its capacity, older entries
+// are dropped
+type Journal struct {
+	mu       sync.Mutex
+	entries  []Entry
+	next     int
+	total    uint64
+	capacity int
+}
+
+var (
+	unmatched     *Journal
+	unmatchedOnce sync.Once
+	requests      *Journal
+	requestsOnce  sync.Once
+)
+
+// Requests returns the journal of every request answered by a mock
+func Requests() *Journal {
+	requestsOnce.Do(func() {
+		requests = New(DefaultCapacity)
+	})
+	return requests
+}
+
+// Unmatched returns the journal of requests no path config matched
+func Unmatched() *Journal {
+	unmatchedOnce.Do(func() {
+		unmatched = New(DefaultCapacity)
+	})
+	return unmatched
+}
+
+// New creates an empty journal keeping up to capacity entries
+func New(capacity int) *Journal {
+	return &Journal{capacity: capacity}
+}
+
+func (j *Journal) Add(e Entry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.total++
+	if len(j.entries) < j.capacity {
+		j.entries = append(j.entries, e)
+		return
+	}
+	j.entries[j.next] = e
+	j.next = (j.next + 1) % j.capacity
+}
+
+// Entries returns the recorded entries, oldest first
+func (j *Journal) Entries() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	result := make([]Entry, 0, len(j.entries))
+	result = append(result, j.entries[j.next:]...)
+	return append(result, j.entries[:j.next]...)
+}
+
+// Total returns the number of entries ever added, including dropped ones
+func (j *Journal) Total() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.total
+}
+
+func (j *Journal) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = nil
+	j.next = 0
+	j.total = 0
+}
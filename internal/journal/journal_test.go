@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	j := New(3)
+	for i := 1; i <= 5; i++ {
+		j.Add(Entry{Path: fmt.Sprintf("/%d", i)})
+	}
+
+	entries := j.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("len = %d, want 3", len(entries))
+	}
+	for i, want := range []string{"/3", "/4", "/5"} {
+		if entries[i].Path != want {
+			t.Errorf("entry %d = %s, want %s", i, entries[i].Path, want)
+		}
+	}
+	if j.Total() != 5 {
+		t.Errorf("total = %d, want 5", j.Total())
+	}
+
+	j.Clear()
+	if len(j.Entries()) != 0 || j.Total() != 0 {
+		t.Error("Clear should drop all entries")
+	}
+}
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		body  string
+		limit int
+		want  string
+	}{
+		{body: "short", limit: 10, want: "short"},
+		{body: "0123456789", limit: 4, want: "0123"},
+		{body: "aé€", limit: 2, want: "a"},
+		{body: "aé€", limit: 5, want: "aé"},
+		{body: "aé€", limit: 6, want: "aé€"},
+		// A capture buffer that stopped in the middle of €
+		{body: "a\xe2\x82", limit: 3, want: "a"},
+	}
+	for _, tt := range tests {
+		if got := string(Cut([]byte(tt.body), tt.limit)); got != tt.want {
+			t.Errorf("Cut(%q, %d) = %q, want %q", tt.body, tt.limit, got, tt.want)
+		}
+	}
+
+	body := strings.Repeat("a", MaxBodyBytes-1) + "é"
+	if got := Truncate(body); got != body[:MaxBodyBytes-1] {
+		t.Errorf("Truncate split é: ends with %q", got[len(got)-2:])
+	}
+}
@@ -1,11 +1,21 @@
 package middleware
 
 import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
 
-	"echo-server/internal/counter"
+	"echo-server/internal/model"
+	"echo-server/internal/namespace"
 	"echo-server/pkg/logger"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type responseWriter struct {
@@ -36,40 +46,125 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Hijack lets WebSocket upgrades take over the underlying connection
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil {
+		rw.status = http.StatusSwitchingProtocols
+		rw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// RequestIDHeader carries the request ID, taken from the request when present
+// and always set on the response
+const RequestIDHeader = "X-Request-Id"
+
+// validRequestID limits incoming request IDs to what IDs and trace headers
+// usually contain, anything else is replaced by a generated ID
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:+/=-]{1,128}$`)
+
 func RequestLogging(next http.Handler) http.Handler {
+	return requestLogging(next, true)
+}
+
+// AdminRequestLogging logs requests to the admin endpoints, counting them
+// only when counted is set so tests can inspect counters without moving them
+func AdminRequestLogging(next http.Handler, counted bool) http.Handler {
+	return requestLogging(next, counted)
+}
+
+func requestLogging(next http.Handler, counted bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rw := newResponseWriter(w)
 
-		// Get counter instance
-		c := counter.GetGlobalCounter()
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		rw.Header().Set(RequestIDHeader, requestID)
+
+		meta := &model.RequestMeta{RequestID: requestID}
+		log := logger.Default().With("requestId", requestID)
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+			log = log.With("traceId", spanContext.TraceID().String())
+		}
+		ctx := model.WithRequestMeta(r.Context(), meta)
+		ctx = logger.NewContext(ctx, log)
+		r = r.WithContext(ctx)
 
-		// Increment both global and path-specific counters
-		globalCount := c.Increment()
-		pathCount := c.IncrementPath(r.URL.Path)
+		// Increment global, path and method counters
+		c := namespace.Counter(r.Context())
+		var globalCount, pathCount uint64
+		if counted {
+			globalCount = c.Increment()
+			pathCount = c.IncrementPath(r.URL.Path)
+			c.IncrementMethod(r.Method)
+		}
 
 		// Log request details with counter information
-		logger.Info("Request #%d (path #%d) started: %s %s %s",
-			globalCount,
-			pathCount,
-			r.RemoteAddr,
-			r.Method,
-			r.URL.Path,
+		log.Log(logger.INFO, "Request started",
+			"requestNumber", globalCount,
+			"pathCount", pathCount,
+			"remoteAddr", r.RemoteAddr,
+			"method", r.Method,
+			"path", r.URL.Path,
 		)
 
 		// Process request
 		next.ServeHTTP(rw, r)
+		if counted {
+			c.IncrementStatus(rw.status)
+		}
 
-		// Log completion
-		duration := time.Since(start)
-		logger.Info("Request #%d completed: %s %s %s status=%d size=%d duration=%v",
-			globalCount,
-			r.RemoteAddr,
-			r.Method,
-			r.URL.Path,
-			rw.status,
-			rw.size,
-			duration,
-		)
+		// Log completion with what the handler did to the request
+		fields := []any{
+			"requestNumber", globalCount,
+			"remoteAddr", r.RemoteAddr,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rw.status,
+			"size", rw.size,
+			"duration", time.Since(start),
+		}
+		if meta.ConfigName != "" {
+			fields = append(fields, "config", meta.ConfigName)
+		}
+		if meta.ErrorInjected {
+			fields = append(fields, "errorInjected", true)
+		}
+		if meta.Delay > 0 {
+			fields = append(fields, "delay", meta.Delay)
+		}
+		if meta.ProxyTarget != "" {
+			fields = append(fields, "proxyTarget", meta.ProxyTarget)
+		}
+		log.Log(logger.INFO, "Request completed", fields...)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ConnectionTracking numbers requests per client connection so the echo can
+// report whether a connection was reused
+func ConnectionTracking(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(model.WithConnectionRequest(r.Context())))
 	})
 }
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"echo-server/internal/namespace"
+)
+
+// Namespaces sends requests to the namespace named by the X-Echo-Namespace
+// header or a /ns/{name} path prefix, which is stripped. The prefix is only
+// recognized for existing namespaces so mocks below /ns/ keep working, an
+// unknown namespace in the header is answered with 404.
+func Namespaces(next http.Handler, registry *namespace.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest, ok := strings.CutPrefix(r.URL.Path, namespace.PathPrefix); ok {
+			name, path, _ := strings.Cut(rest, "/")
+			if ns, ok := registry.Use(name); ok {
+				r2 := r.Clone(namespace.NewContext(r.Context(), ns))
+				r2.URL.Path = "/" + path
+				r2.URL.RawPath = ""
+				next.ServeHTTP(w, r2)
+				return
+			}
+		}
+
+		if name := r.Header.Get(namespace.Header); name != "" {
+			ns, ok := registry.Use(name)
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"error": "unknown namespace " + name})
+				return
+			}
+			r = r.WithContext(namespace.NewContext(r.Context(), ns))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+)
+
+type connStateKey struct{}
+type connRequestKey struct{}
+
+// connState is shared by every request served on one client connection
+type connState struct {
+	requests  atomic.Int64
+	localAddr string
+}
+
+// NewConnContext is meant for http.Server.ConnContext, it attaches per
+// connection state used to number requests on a connection
+func NewConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connStateKey{}, &connState{
+		localAddr: c.LocalAddr().String(),
+	})
+}
+
+// WithConnectionRequest numbers the request within its connection. It returns
+// ctx unchanged when the connection was not set up with NewConnContext.
+func WithConnectionRequest(ctx context.Context) context.Context {
+	state, ok := ctx.Value(connStateKey{}).(*connState)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, connRequestKey{}, state.requests.Add(1))
+}
+
+func extractConnectionInfo(ctx context.Context) *ConnectionInfo {
+	state, ok := ctx.Value(connStateKey{}).(*connState)
+	if !ok {
+		return nil
+	}
+	number, _ := ctx.Value(connRequestKey{}).(int64)
+
+	return &ConnectionInfo{
+		LocalAddr:     state.localAddr,
+		RequestNumber: number,
+		Reused:        number > 1,
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+type requestMetaKey struct{}
+
+// RequestMeta collects what happened while a request was handled so the
+// logging middleware can report it once the handler returns. It is written
+// by the handler goroutine only.
+type RequestMeta struct {
+	RequestID     string
+	ConfigName    string
+	ErrorInjected bool
+	Delay         time.Duration
+	ProxyTarget   string
+	// RedactHeaders are the headers the matched config masks in logs
+	RedactHeaders []string
+}
+
+// WithRequestMeta returns a context carrying meta
+func WithRequestMeta(ctx context.Context, meta *RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// RequestMetaFromContext returns the RequestMeta of the request. Requests
+// that did not pass through the logging middleware get a throwaway value so
+// callers never need a nil check.
+func RequestMetaFromContext(ctx context.Context) *RequestMeta {
+	if meta, ok := ctx.Value(requestMetaKey{}).(*RequestMeta); ok {
+		return meta
+	}
+	return &RequestMeta{}
+}
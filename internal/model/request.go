@@ -1,12 +1,18 @@
 package model
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type RequestData struct {
+	RequestID   string              `json:"requestId,omitempty"`
 	Method      string              `json:"method"`
 	Path        string              `json:"path"`
 	QueryParams url.Values          `json:"queryParams"`
@@ -16,6 +22,41 @@ type RequestData struct {
 	Host        string              `json:"host"`
 	Protocol    string              `json:"protocol"`
 	Counter     CounterInfo         `json:"counter"`
+	TLS         *TLSInfo            `json:"tls,omitempty"`
+	Connection  *ConnectionInfo     `json:"connection,omitempty"`
+}
+
+// TLSInfo describes the TLS connection a request arrived on
+type TLSInfo struct {
+	Version            string           `json:"version"`
+	CipherSuite        string           `json:"cipherSuite"`
+	NegotiatedProtocol string           `json:"alpn,omitempty"`
+	ServerName         string           `json:"serverName,omitempty"`
+	Resumed            bool             `json:"resumed"`
+	ClientCertificate  *CertificateInfo `json:"clientCertificate,omitempty"`
+}
+
+// ConnectionInfo describes the client connection a request arrived on. There
+// is no HTTP/2 stream id, net/http does not expose it.
+type ConnectionInfo struct {
+	LocalAddr     string `json:"localAddr"`
+	RequestNumber int64  `json:"requestNumber"`
+	Reused        bool   `json:"reused"`
+}
+
+// CertificateInfo holds the details of a presented client certificate
+type CertificateInfo struct {
+	Subject        string    `json:"subject"`
+	Issuer         string    `json:"issuer"`
+	SerialNumber   string    `json:"serialNumber"`
+	DNSNames       []string  `json:"dnsNames,omitempty"`
+	EmailAddresses []string  `json:"emailAddresses,omitempty"`
+	IPAddresses    []string  `json:"ipAddresses,omitempty"`
+	URIs           []string  `json:"uris,omitempty"`
+	NotBefore      time.Time `json:"notBefore"`
+	NotAfter       time.Time `json:"notAfter"`
+	Fingerprint    string    `json:"fingerprintSha256"`
+	Verified       bool      `json:"verified"`
 }
 
 type CounterInfo struct {
@@ -33,6 +74,7 @@ func ExtractRequestData(r *http.Request) (*RequestData, error) {
 
 	// Create request data
 	data := &RequestData{
+		RequestID:   RequestMetaFromContext(r.Context()).RequestID,
 		Method:      r.Method,
 		Path:        r.URL.Path,
 		QueryParams: r.URL.Query(),
@@ -43,5 +85,48 @@ func ExtractRequestData(r *http.Request) (*RequestData, error) {
 		Protocol:    r.Proto,
 	}
 
+	if r.TLS != nil {
+		data.TLS = extractTLSInfo(r.TLS)
+	}
+	data.Connection = extractConnectionInfo(r.Context())
+
 	return data, nil
 }
+
+func extractTLSInfo(state *tls.ConnectionState) *TLSInfo {
+	info := &TLSInfo{
+		Version:            tls.VersionName(state.Version),
+		CipherSuite:        tls.CipherSuiteName(state.CipherSuite),
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		ServerName:         state.ServerName,
+		Resumed:            state.DidResume,
+	}
+
+	if len(state.PeerCertificates) > 0 {
+		info.ClientCertificate = newCertificateInfo(state.PeerCertificates[0])
+		info.ClientCertificate.Verified = len(state.VerifiedChains) > 0
+	}
+
+	return info
+}
+
+func newCertificateInfo(cert *x509.Certificate) *CertificateInfo {
+	fingerprint := sha256.Sum256(cert.Raw)
+	info := &CertificateInfo{
+		Subject:        cert.Subject.String(),
+		Issuer:         cert.Issuer.String(),
+		SerialNumber:   cert.SerialNumber.String(),
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		info.URIs = append(info.URIs, uri.String())
+	}
+	return info
+}
@@ -0,0 +1,350 @@
+package namespace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+	"echo-server/internal/journal"
+	"echo-server/pkg/logger"
+)
+
+// Header selects the namespace of a request
+const Header = "X-Echo-Namespace"
+
+// PathPrefix selects the namespace of a request by path: /ns/{name}/users is
+// /users in namespace {name}
+const PathPrefix = "/ns/"
+
+var (
+	ErrExists      = errors.New("namespace already exists")
+	ErrInvalidName = errors.New("namespace names are 1 to 64 letters, digits, '.', '_' or '-'")
+)
+
+// CleanupInterval is how often the server removes expired namespaces
+const CleanupInterval = 10 * time.Second
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Namespace isolates a set of path configs with their own counters and
+// journals from the rest of the server. Server settings other than the path
+// configs are copied from the server when the namespace is created.
+type Namespace struct {
+	Name      string
+	Created   time.Time
+	TTL       time.Duration
+	Config    *config.ServerConfig
+	Counter   *counter.Counter
+	Requests  *journal.Journal
+	Unmatched *journal.Journal
+	lastUsed  atomic.Int64
+}
+
+// Info describes a namespace. ExpiresAt is unset for namespaces without TTL.
+type Info struct {
+	Name      string          `json:"name"`
+	Created   time.Time       `json:"created"`
+	TTL       config.Duration `json:"ttl"`
+	LastUsed  time.Time       `json:"lastUsed"`
+	ExpiresAt *time.Time      `json:"expiresAt,omitempty"`
+	Configs   int             `json:"configs"`
+	Requests  uint64          `json:"requests"`
+}
+
+// Info returns a description of the namespace
+func (ns *Namespace) Info() Info {
+	info := Info{
+		Name:     ns.Name,
+		Created:  ns.Created,
+		TTL:      config.Duration{Duration: ns.TTL},
+		LastUsed: ns.LastUsed(),
+		Configs:  len(ns.Config.PathMatcher.GetAllConfigs()),
+		Requests: ns.Counter.GetCount(),
+	}
+	if ns.TTL > 0 {
+		expires := info.LastUsed.Add(ns.TTL)
+		info.ExpiresAt = &expires
+	}
+	return info
+}
+
+// LastUsed returns when the namespace was created or last selected
+func (ns *Namespace) LastUsed() time.Time {
+	return time.Unix(0, ns.lastUsed.Load()).UTC()
+}
+
+func (ns *Namespace) touch(now time.Time) {
+	ns.lastUsed.Store(now.UnixNano())
+}
+
+// expired reports whether the namespace has been unused for its TTL
+func (ns *Namespace) expired(now time.Time) bool {
+	return ns.TTL > 0 && now.Sub(ns.LastUsed()) >= ns.TTL
+}
+
+// Registry holds the namespaces of a server
+type Registry struct {
+	mu         sync.RWMutex
+	namespaces map[string]*Namespace
+	// restored are counters of namespaces that did not exist when they
+	// were restored, waiting for the namespace to be created again
+	restored map[string]counter.Snapshot
+}
+
+var (
+	defaultRegistry *Registry
+	defaultOnce     sync.Once
+)
+
+// Default returns the registry shared by all listeners
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		defaultRegistry = NewRegistry()
+	})
+	return defaultRegistry
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{namespaces: make(map[string]*Namespace), restored: make(map[string]counter.Snapshot)}
+}
+
+// Create adds a namespace with the settings of base. A name is generated
+// when name is empty. With copyPaths it starts with base's path configs,
+// otherwise with none. A namespace unused for ttl is removed, zero keeps it
+// until it is deleted.
+func (r *Registry) Create(name string, base *config.ServerConfig, ttl time.Duration, copyPaths bool) (*Namespace, error) {
+	if name == "" {
+		name = generateName()
+	}
+	if !validName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("ttl must not be negative")
+	}
+
+	cfg := *base
+	cfg.Listeners = nil
+	cfg.PathMatcher = config.NewPathMatcher()
+	cfg.History = config.NewHistory(base.HistoryLimit)
+	if copyPaths {
+		paths := base.PathMatcher.GetAllConfigs()
+		if err := cfg.PathMatcher.Replace(paths); err != nil {
+			return nil, err
+		}
+		cfg.History.Record("", "create namespace "+name, nil, paths)
+	}
+
+	now := time.Now().UTC()
+	ns := &Namespace{
+		Name:      name,
+		Created:   now,
+		TTL:       ttl,
+		Config:    &cfg,
+		Counter:   counter.New(),
+		Requests:  journal.New(journal.DefaultCapacity),
+		Unmatched: journal.New(journal.DefaultCapacity),
+	}
+	ns.touch(now)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.namespaces[name]; ok {
+		return nil, ErrExists
+	}
+	if snapshot, ok := r.restored[name]; ok {
+		if err := ns.Counter.Restore(snapshot); err != nil {
+			return nil, err
+		}
+		delete(r.restored, name)
+	}
+	r.namespaces[name] = ns
+	logger.Info("Created namespace %s", name)
+	return ns, nil
+}
+
+// Get returns the namespace called name
+func (r *Registry) Get(name string) (*Namespace, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ns, ok := r.namespaces[name]
+	return ns, ok
+}
+
+// Use returns the namespace called name for a request sent to it, which
+// restarts its TTL
+func (r *Registry) Use(name string) (*Namespace, bool) {
+	ns, ok := r.Get(name)
+	if ok {
+		ns.touch(time.Now())
+	}
+	return ns, ok
+}
+
+// Delete removes the namespace called name
+func (r *Registry) Delete(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.namespaces[name]; !ok {
+		return false
+	}
+	delete(r.namespaces, name)
+	logger.Info("Deleted namespace %s", name)
+	return true
+}
+
+// List returns all namespaces ordered by name
+func (r *Registry) List() []*Namespace {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*Namespace, 0, len(r.namespaces))
+	for _, ns := range r.namespaces {
+		list = append(list, ns)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// CounterSnapshots returns the counters of every namespace, including
+// restored ones of namespaces not created again yet
+func (r *Registry) CounterSnapshots() map[string]counter.Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshots := make(map[string]counter.Snapshot, len(r.namespaces)+len(r.restored))
+	for name, snapshot := range r.restored {
+		snapshots[name] = snapshot
+	}
+	for name, ns := range r.namespaces {
+		snapshots[name] = ns.Counter.Snapshot()
+	}
+	return snapshots
+}
+
+// RestoreCounters restores the counters of existing namespaces and keeps
+// the others until a namespace with that name is created. Namespaces and
+// their path configs are not persisted, so after a restart every namespace
+// gets its counters back when it is created again.
+func (r *Registry) RestoreCounters(snapshots map[string]counter.Snapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, snapshot := range snapshots {
+		if err := snapshot.Validate(); err != nil {
+			return fmt.Errorf("namespace %s: %w", name, err)
+		}
+		if ns, ok := r.namespaces[name]; ok {
+			if err := ns.Counter.Restore(snapshot); err != nil {
+				return err
+			}
+			continue
+		}
+		r.restored[name] = snapshot
+	}
+	return nil
+}
+
+// Expire removes the namespaces that have been unused for their TTL at now
+// and returns their names
+func (r *Registry) Expire(now time.Time) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []string
+	for name, ns := range r.namespaces {
+		if ns.expired(now) {
+			delete(r.namespaces, name)
+			expired = append(expired, name)
+			logger.Info("Namespace %s expired after %s unused", name, ns.TTL)
+		}
+	}
+	sort.Strings(expired)
+	return expired
+}
+
+// StartCleanup removes expired namespaces every interval until stop is
+// called
+func (r *Registry) StartCleanup(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				r.Expire(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func generateName() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "ns-" + hex.EncodeToString(b)
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying the namespace of a request
+func NewContext(ctx context.Context, ns *Namespace) context.Context {
+	return context.WithValue(ctx, contextKey{}, ns)
+}
+
+// FromContext returns the namespace a request was sent to, if any
+func FromContext(ctx context.Context) (*Namespace, bool) {
+	ns, ok := ctx.Value(contextKey{}).(*Namespace)
+	return ns, ok
+}
+
+// Counter returns the counters of the request's namespace, the global
+// counters outside of namespaces
+func Counter(ctx context.Context) *counter.Counter {
+	if ns, ok := FromContext(ctx); ok {
+		return ns.Counter
+	}
+	return counter.GetGlobalCounter()
+}
+
+// Requests returns the request journal of the request's namespace, the
+// global one outside of namespaces
+func Requests(ctx context.Context) *journal.Journal {
+	if ns, ok := FromContext(ctx); ok {
+		return ns.Requests
+	}
+	return journal.Requests()
+}
+
+// Unmatched returns the unmatched requests of the request's namespace, the
+// global ones outside of namespaces
+func Unmatched(ctx context.Context) *journal.Journal {
+	if ns, ok := FromContext(ctx); ok {
+		return ns.Unmatched
+	}
+	return journal.Unmatched()
+}
+
+// Config returns the configuration of the request's namespace, cfg outside
+// of namespaces
+func Config(ctx context.Context, cfg *config.ServerConfig) *config.ServerConfig {
+	if ns, ok := FromContext(ctx); ok {
+		return ns.Config
+	}
+	return cfg
+}
@@ -0,0 +1,148 @@
+package namespace
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+)
+
+func newBase(t *testing.T) *config.ServerConfig {
+	t.Helper()
+	base := &config.ServerConfig{PathMatcher: config.NewPathMatcher(), Strict: true}
+	if err := base.PathMatcher.Add(&config.PathConfig{Name: "users", Pattern: "^/users$"}); err != nil {
+		t.Fatal(err)
+	}
+	return base
+}
+
+func TestRegistryCreate(t *testing.T) {
+	r := NewRegistry()
+	base := newBase(t)
+
+	empty, err := r.Create("suite-a", base, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !empty.Config.Strict || len(empty.Config.PathMatcher.GetAllConfigs()) != 0 {
+		t.Errorf("namespace config = %+v, want the server settings without paths", empty.Config)
+	}
+
+	copied, err := r.Create("", base, time.Minute, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validName.MatchString(copied.Name) || copied.Name == "suite-a" {
+		t.Errorf("generated name = %q", copied.Name)
+	}
+	if _, ok := copied.Config.PathMatcher.Match("/users", "GET"); !ok {
+		t.Error("copied paths not matched")
+	}
+	if err := copied.Config.PathMatcher.Replace(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := base.PathMatcher.Match("/users", "GET"); !ok {
+		t.Error("changing the namespace changed the server's paths")
+	}
+
+	if _, err := r.Create("suite-a", base, 0, false); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate name error = %v", err)
+	}
+	if _, err := r.Create("../etc", base, 0, false); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("invalid name error = %v", err)
+	}
+
+	if !r.Delete("suite-a") || r.Delete("suite-a") {
+		t.Error("Delete() should succeed exactly once")
+	}
+	if got := len(r.List()); got != 1 {
+		t.Errorf("namespaces = %d, want 1", got)
+	}
+}
+
+func TestRegistryExpire(t *testing.T) {
+	r := NewRegistry()
+	base := newBase(t)
+	for name, ttl := range map[string]time.Duration{"short": time.Minute, "long": time.Hour, "forever": 0} {
+		if _, err := r.Create(name, base, ttl, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	if expired := r.Expire(now); len(expired) != 0 {
+		t.Errorf("expired right away: %v", expired)
+	}
+	if expired := r.Expire(now.Add(2 * time.Minute)); !reflect.DeepEqual(expired, []string{"short"}) {
+		t.Errorf("expired = %v, want [short]", expired)
+	}
+
+	// Using a namespace restarts its TTL
+	long, _ := r.Get("long")
+	long.touch(now.Add(50 * time.Minute))
+	if expired := r.Expire(now.Add(61 * time.Minute)); len(expired) != 0 {
+		t.Errorf("expired although used: %v", expired)
+	}
+	if info := long.Info(); info.ExpiresAt == nil || !info.ExpiresAt.Equal(now.Add(110*time.Minute).UTC()) {
+		t.Errorf("expiresAt = %v", info.ExpiresAt)
+	}
+	if expired := r.Expire(now.Add(24 * time.Hour)); !reflect.DeepEqual(expired, []string{"long"}) {
+		t.Errorf("expired = %v, want [long]", expired)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if Counter(ctx) != counter.GetGlobalCounter() {
+		t.Error("requests outside namespaces should use the global counter")
+	}
+
+	ns, err := NewRegistry().Create("suite", newBase(t), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx = NewContext(ctx, ns)
+	if Counter(ctx) != ns.Counter || Requests(ctx) != ns.Requests || Unmatched(ctx) != ns.Unmatched || Config(ctx, nil) != ns.Config {
+		t.Error("requests in a namespace should use its state")
+	}
+}
+
+func TestRegistryRestoreCounters(t *testing.T) {
+	r := NewRegistry()
+	base := newBase(t)
+	live, err := r.Create("live", base, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.RestoreCounters(map[string]counter.Snapshot{
+		"live":  {Global: 3},
+		"later": {Global: 7, Configs: map[string]uint64{"users": 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := live.Counter.GetCount(); got != 3 {
+		t.Errorf("live count = %d, want 3", got)
+	}
+
+	// Counters of namespaces not created yet are kept in snapshots
+	if got := r.CounterSnapshots()["later"].Global; got != 7 {
+		t.Errorf("snapshot of later = %d, want 7", got)
+	}
+	later, err := r.Create("later", base, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if later.Counter.GetCount() != 7 || later.Counter.GetConfigCount("users") != 2 {
+		t.Errorf("later counters = %d, %d, want 7, 2", later.Counter.GetCount(), later.Counter.GetConfigCount("users"))
+	}
+
+	if err := r.RestoreCounters(map[string]counter.Snapshot{"bad": {Statuses: map[string]uint64{"ok": 1}}}); err == nil {
+		t.Error("expected an error for an invalid snapshot")
+	}
+}
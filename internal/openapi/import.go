@@ -0,0 +1,295 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"echo-server/internal/config"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ImportOptions tune how a document is converted
+type ImportOptions struct {
+	// BasePath is prepended to every path. When empty the path of the
+	// first server URL is used.
+	BasePath string
+}
+
+// maxSchemaDepth stops synthesizing examples for deeply nested or
+// recursive schemas
+const maxSchemaDepth = 8
+
+var pathParam = regexp.MustCompile(`\{[^}/]+\}`)
+
+// Load parses and validates an OpenAPI 3 document in JSON or YAML
+func Load(ctx context.Context, data []byte) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	doc, err := loader.LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return doc, nil
+}
+
+// Import converts every operation of an OpenAPI 3 document into a path
+// config. Concrete paths come before templated ones so they are matched
+// first.
+func Import(ctx context.Context, data []byte, opts ImportOptions) ([]config.PathConfig, error) {
+	doc, err := Load(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := opts.BasePath
+	if basePath == "" {
+		if basePath, err = doc.Servers.BasePath(); err != nil {
+			return nil, fmt.Errorf("reading server base path: %w", err)
+		}
+	}
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	var configs []config.PathConfig
+	for _, path := range doc.Paths.InMatchingOrder() {
+		item := doc.Paths.Value(path)
+		for _, method := range sortedMethods(item) {
+			op := item.GetOperation(method)
+			pc, err := operationConfig(basePath, path, method, op)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			configs = append(configs, pc)
+		}
+	}
+	return configs, nil
+}
+
+// PathPattern converts an OpenAPI path template such as /users/{id} into an
+// anchored regex where every parameter matches one path segment
+func PathPattern(basePath, path string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	full := basePath + path
+	for _, loc := range pathParam.FindAllStringIndex(full, -1) {
+		b.WriteString(regexp.QuoteMeta(full[last:loc[0]]))
+		b.WriteString("[^/]+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(full[last:]))
+	b.WriteString("$")
+	return b.String()
+}
+
+func sortedMethods(item *openapi3.PathItem) []string {
+	methods := make([]string, 0, len(item.Operations()))
+	for method := range item.Operations() {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func operationConfig(basePath, path, method string, op *openapi3.Operation) (config.PathConfig, error) {
+	name := op.OperationID
+	if name == "" || strings.Contains(name, "/") {
+		name = config.GeneratedName(method, path)
+	}
+
+	pc := config.PathConfig{
+		Name:     name,
+		Pattern:  PathPattern(basePath, path),
+		Methods:  []string{method},
+		Response: config.ResponseConfig{StatusCode: http.StatusOK, Headers: map[string]string{}},
+	}
+
+	status, response := successResponse(op)
+	if response == nil {
+		return pc, nil
+	}
+	pc.Response.StatusCode = status
+
+	mediaType, media := preferredContent(response.Content)
+	if media == nil || status == http.StatusNoContent {
+		return pc, nil
+	}
+	pc.Response.Headers["Content-Type"] = mediaType
+
+	example, ok := mediaExample(media)
+	if !ok {
+		return pc, nil
+	}
+	if s, isString := example.(string); isString && !strings.Contains(mediaType, "json") {
+		pc.Response.Body = s
+		return pc, nil
+	}
+	body, err := json.Marshal(example)
+	if err != nil {
+		return pc, fmt.Errorf("encoding example: %w", err)
+	}
+	pc.Response.Body = string(body)
+	return pc, nil
+}
+
+// successResponse picks the lowest 2xx response, falling back to the default
+// response and then to the lowest status code documented
+func successResponse(op *openapi3.Operation) (int, *openapi3.Response) {
+	if op.Responses == nil {
+		return http.StatusOK, nil
+	}
+
+	responses := op.Responses.Map()
+	var codes []int
+	for key := range responses {
+		if code, err := strconv.Atoi(key); err == nil {
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		if code >= 200 && code < 300 {
+			return code, responses[strconv.Itoa(code)].Value
+		}
+	}
+	if def := op.Responses.Default(); def != nil {
+		return http.StatusOK, def.Value
+	}
+	if len(codes) > 0 {
+		return codes[0], responses[strconv.Itoa(codes[0])].Value
+	}
+	return http.StatusOK, nil
+}
+
+// preferredContent prefers JSON, otherwise the first media type by name
+func preferredContent(content openapi3.Content) (string, *openapi3.MediaType) {
+	if len(content) == 0 {
+		return "", nil
+	}
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+	for _, mediaType := range types {
+		if strings.Contains(mediaType, "json") {
+			return mediaType, content[mediaType]
+		}
+	}
+	return types[0], content[types[0]]
+}
+
+// mediaExample returns the example, the first named example or an example
+// synthesized from the schema
+func mediaExample(media *openapi3.MediaType) (interface{}, bool) {
+	if media.Example != nil {
+		return media.Example, true
+	}
+	if len(media.Examples) > 0 {
+		names := make([]string, 0, len(media.Examples))
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ex := media.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+				return ex.Value.Value, true
+			}
+		}
+	}
+	if media.Schema != nil && media.Schema.Value != nil {
+		return SchemaExample(media.Schema.Value), true
+	}
+	return nil, false
+}
+
+// SchemaExample builds an example value for a schema from its examples,
+// enums and defaults, or from placeholder values of the right type
+func SchemaExample(schema *openapi3.Schema) interface{} {
+	return schemaExample(schema, 0)
+}
+
+func schemaExample(schema *openapi3.Schema, depth int) interface{} {
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.AllOf) > 0:
+		merged := map[string]interface{}{}
+		for _, ref := range schema.AllOf {
+			if part, ok := schemaExample(ref.Value, depth+1).(map[string]interface{}); ok {
+				for k, v := range part {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return schemaExample(schema.OneOf[0].Value, depth+1)
+	case len(schema.AnyOf) > 0:
+		return schemaExample(schema.AnyOf[0].Value, depth+1)
+	}
+
+	switch {
+	case schema.Type.Is(openapi3.TypeObject) || len(schema.Properties) > 0:
+		obj := make(map[string]interface{}, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			obj[name] = schemaExample(prop.Value, depth+1)
+		}
+		return obj
+	case schema.Type.Is(openapi3.TypeArray):
+		if schema.Items == nil {
+			return []interface{}{}
+		}
+		return []interface{}{schemaExample(schema.Items.Value, depth+1)}
+	case schema.Type.Is(openapi3.TypeString):
+		return stringExample(schema.Format)
+	case schema.Type.Is(openapi3.TypeInteger):
+		if schema.Min != nil {
+			return int64(*schema.Min)
+		}
+		return 0
+	case schema.Type.Is(openapi3.TypeNumber):
+		if schema.Min != nil {
+			return *schema.Min
+		}
+		return 0.0
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return true
+	}
+	return nil
+}
+
+func stringExample(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	}
+	return "string"
+}
@@ -0,0 +1,243 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getPet
+      responses:
+        "200":
+          description: A pet
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+        "404":
+          description: Not found
+    delete:
+      responses:
+        "204":
+          description: Deleted
+  /pets/mine:
+    get:
+      operationId: myPets
+      responses:
+        "200":
+          description: My pets
+          content:
+            application/json:
+              example: [{"id": "rex", "name": "Rex"}]
+  /pets:
+    post:
+      operationId: createPet
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              examples:
+                b-second:
+                  value: {"id": "b"}
+                a-first:
+                  value: {"id": "a"}
+        "400":
+          description: Bad request
+  /health:
+    get:
+      operationId: health
+      responses:
+        default:
+          description: Status
+          content:
+            text/plain:
+              example: ok
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: string
+          format: uuid
+        name:
+          type: string
+          example: Rex
+        age:
+          type: integer
+          minimum: 1
+        tags:
+          type: array
+          items:
+            type: string
+            enum: [cute, loud]
+`
+
+func TestImport(t *testing.T) {
+	configs, err := Import(context.Background(), []byte(petstore), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := make(map[string]int, len(configs))
+	for i, pc := range configs {
+		index[pc.Name] = i
+	}
+	if len(configs) != 5 {
+		t.Fatalf("got %d configs, want 5", len(configs))
+	}
+	// Concrete paths are matched before templated ones
+	if index["myPets"] > index["getPet"] || index["myPets"] > index["delete-pets-id"] {
+		t.Errorf("/pets/mine must come before /pets/{id}, got order %v", index)
+	}
+
+	tests := []struct {
+		name        string
+		wantPattern string
+		wantMethod  string
+		wantStatus  int
+		wantType    string
+		wantBody    interface{}
+		wantRawBody string
+	}{
+		{
+			name:        "health",
+			wantPattern: `^/v1/health$`,
+			wantMethod:  "GET",
+			wantStatus:  200,
+			wantType:    "text/plain",
+			wantRawBody: "ok",
+		},
+		{
+			name:        "createPet",
+			wantPattern: `^/v1/pets$`,
+			wantMethod:  "POST",
+			wantStatus:  201,
+			wantType:    "application/json",
+			wantBody:    map[string]interface{}{"id": "a"},
+		},
+		{
+			name:        "myPets",
+			wantPattern: `^/v1/pets/mine$`,
+			wantMethod:  "GET",
+			wantStatus:  200,
+			wantType:    "application/json",
+			wantBody:    []interface{}{map[string]interface{}{"id": "rex", "name": "Rex"}},
+		},
+		{
+			name:        "delete-pets-id",
+			wantPattern: `^/v1/pets/[^/]+$`,
+			wantMethod:  "DELETE",
+			wantStatus:  204,
+		},
+		{
+			name:        "getPet",
+			wantPattern: `^/v1/pets/[^/]+$`,
+			wantMethod:  "GET",
+			wantStatus:  200,
+			wantType:    "application/json",
+			wantBody: map[string]interface{}{
+				"id":   "00000000-0000-0000-0000-000000000000",
+				"name": "Rex",
+				"age":  float64(1),
+				"tags": []interface{}{"cute"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, ok := index[tt.name]
+			if !ok {
+				t.Fatalf("config %q not imported", tt.name)
+			}
+			pc := configs[i]
+			if pc.Pattern != tt.wantPattern {
+				t.Errorf("pattern = %q, want %q", pc.Pattern, tt.wantPattern)
+			}
+			if !reflect.DeepEqual(pc.Methods, []string{tt.wantMethod}) {
+				t.Errorf("methods = %v, want [%s]", pc.Methods, tt.wantMethod)
+			}
+			if pc.Response.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", pc.Response.StatusCode, tt.wantStatus)
+			}
+			if got := pc.Response.Headers["Content-Type"]; got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			switch {
+			case tt.wantBody != nil:
+				var body interface{}
+				if err := json.Unmarshal([]byte(pc.Response.Body), &body); err != nil {
+					t.Fatalf("body %q: %v", pc.Response.Body, err)
+				}
+				if !reflect.DeepEqual(body, tt.wantBody) {
+					t.Errorf("body = %v, want %v", body, tt.wantBody)
+				}
+			case pc.Response.Body != tt.wantRawBody:
+				t.Errorf("body = %q, want %q", pc.Response.Body, tt.wantRawBody)
+			}
+		})
+	}
+}
+
+func TestImportOptions(t *testing.T) {
+	configs, err := Import(context.Background(), []byte(petstore), ImportOptions{BasePath: "/mock/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pc := range configs {
+		if pc.Name == "health" && pc.Pattern != `^/mock/health$` {
+			t.Errorf("pattern = %q, want ^/mock/health$", pc.Pattern)
+		}
+	}
+
+	if _, err := Import(context.Background(), []byte("openapi: 3.0.3\ninfo: {}\n"), ImportOptions{}); err == nil {
+		t.Error("expected an error for an invalid document")
+	}
+}
+
+func TestPathPattern(t *testing.T) {
+	tests := []struct {
+		basePath string
+		path     string
+		match    []string
+		noMatch  []string
+	}{
+		{path: "/users", match: []string{"/users"}, noMatch: []string{"/users/1", "/api/users"}},
+		{path: "/users/{id}/posts/{postId}", match: []string{"/users/1/posts/abc"}, noMatch: []string{"/users/1/posts", "/users/1/2/posts/3"}},
+		{basePath: "/api", path: "/files/{name}.json", match: []string{"/api/files/a.json"}, noMatch: []string{"/api/files/ajson"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			re := regexp.MustCompile(PathPattern(tt.basePath, tt.path))
+			for _, p := range tt.match {
+				if !re.MatchString(p) {
+					t.Errorf("%s does not match %s", re, p)
+				}
+			}
+			for _, p := range tt.noMatch {
+				if re.MatchString(p) {
+					t.Errorf("%s matches %s", re, p)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"echo-server/internal/config"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// Violation is one way a request breaks the API description. In is "path",
+// "query", "header" or "cookie" for parameters, "body", "security" or
+// "route" when no operation describes the request. Field is the parameter
+// name or a JSON pointer into the body.
+type Violation struct {
+	In      string `json:"in"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationReport lists every violation found in a request
+type ValidationReport struct {
+	Error      string      `json:"error"`
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Operation  string      `json:"operation,omitempty"`
+	Violations []Violation `json:"violations"`
+}
+
+// Undocumented reports whether the request failed because no operation
+// describes it
+func (r *ValidationReport) Undocumented() bool {
+	return len(r.Violations) == 1 && r.Violations[0].In == "route"
+}
+
+// Validator checks requests against an OpenAPI 3 document
+type Validator struct {
+	router  routers.Router
+	options *openapi3filter.Options
+}
+
+// NewValidator prepares a validator for doc. Requests are matched on their
+// path only, below basePath or the path of the first server URL, whatever
+// host the server is reached on.
+func NewValidator(doc *openapi3.T, basePath string) (*Validator, error) {
+	if basePath == "" {
+		var err error
+		if basePath, err = doc.Servers.BasePath(); err != nil {
+			return nil, fmt.Errorf("reading server base path: %w", err)
+		}
+	}
+	doc.Servers = openapi3.Servers{{URL: strings.TrimSuffix(basePath, "/")}}
+	for _, item := range doc.Paths.Map() {
+		item.Servers = nil
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("building router: %w", err)
+	}
+	return &Validator{
+		router: router,
+		options: &openapi3filter.Options{
+			MultiError:          true,
+			SkipSettingDefaults: true,
+			// Mocks accept any credentials, only their presence is checked
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}, nil
+}
+
+// Validate returns nil when the request conforms to the document, otherwise
+// a report of every violation. The request body is consumed.
+func (v *Validator) Validate(r *http.Request) *ValidationReport {
+	report := &ValidationReport{Method: r.Method, Path: r.URL.Path}
+
+	route, params, err := v.router.FindRoute(r)
+	if err != nil {
+		report.Error = "no operation of the API description matches the request"
+		report.Violations = []Violation{{In: "route", Message: err.Error()}}
+		return report
+	}
+	report.Operation = route.Operation.OperationID
+	if report.Operation == "" {
+		report.Operation = route.Method + " " + route.Path
+	}
+
+	err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: params,
+		Route:      route,
+		Options:    v.options,
+	})
+	if err == nil {
+		return nil
+	}
+	report.Error = "request violates the API description"
+	collectViolations(err, Violation{}, &report.Violations)
+	return report
+}
+
+// collectViolations flattens the nested errors of the validation library,
+// carrying the parameter or body the error is about down to schema errors
+func collectViolations(err error, v Violation, out *[]Violation) {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, sub := range e {
+			collectViolations(sub, v, out)
+		}
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			v.In, v.Field = e.Parameter.In, e.Parameter.Name
+		case e.RequestBody != nil:
+			v.In = "body"
+		}
+		v.Message = e.Reason
+		if e.Err == nil {
+			*out = append(*out, v)
+			return
+		}
+		collectViolations(e.Err, v, out)
+	case *openapi3filter.SecurityRequirementsError:
+		*out = append(*out, Violation{In: "security", Message: e.Error()})
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 {
+			v.Field = "/" + strings.Join(pointer, "/")
+		}
+		v.Message = e.Reason
+		*out = append(*out, v)
+	default:
+		if v.Message != "" && v.Message != err.Error() {
+			v.Message += ": " + err.Error()
+		} else {
+			v.Message = err.Error()
+		}
+		*out = append(*out, v)
+	}
+}
+
+type cachedValidator struct {
+	modTime   time.Time
+	size      int64
+	validator *Validator
+}
+
+var validators = struct {
+	sync.Mutex
+	entries map[string]cachedValidator
+}{entries: make(map[string]cachedValidator)}
+
+// ValidatorFor returns the validator for a request validation config. The
+// document is loaded on first use and again whenever the file changes.
+func ValidatorFor(vc *config.RequestValidationConfig) (*Validator, error) {
+	info, err := os.Stat(vc.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	key := vc.Spec + "\x00" + vc.BasePath
+	validators.Lock()
+	defer validators.Unlock()
+	if cached, ok := validators.entries[key]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.validator, nil
+	}
+
+	data, err := os.ReadFile(vc.Spec)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Load(context.Background(), data)
+	if err != nil {
+		return nil, err
+	}
+	validator, err := NewValidator(doc, vc.BasePath)
+	if err != nil {
+		return nil, err
+	}
+	validators.entries[key] = cachedValidator{modTime: info.ModTime(), size: info.Size(), validator: validator}
+	return validator, nil
+}
@@ -0,0 +1,251 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"echo-server/internal/config"
+)
+
+const ordersSpec = `
+openapi: 3.0.3
+info:
+  title: Orders
+  version: "1.0"
+servers:
+  - url: https://orders.example.com/api
+paths:
+  /orders:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+            maximum: 100
+      responses:
+        "200":
+          description: Orders
+    post:
+      operationId: createOrder
+      parameters:
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [item, quantity]
+              properties:
+                item:
+                  type: string
+                quantity:
+                  type: integer
+                  minimum: 1
+      responses:
+        "201":
+          description: Created
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: An order
+`
+
+func TestValidator(t *testing.T) {
+	doc, err := Load(context.Background(), []byte(ordersSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewValidator(doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		headers        map[string]string
+		body           string
+		wantValid      bool
+		wantOperation  string
+		wantViolations []Violation
+	}{
+		{
+			name:      "valid query",
+			method:    "GET",
+			target:    "http://localhost:8080/api/orders?limit=10",
+			wantValid: true,
+		},
+		{
+			name:          "missing required query parameter",
+			method:        "GET",
+			target:        "/api/orders",
+			wantOperation: "GET /orders",
+			wantViolations: []Violation{
+				{In: "query", Field: "limit", Message: "value is required but missing"},
+			},
+		},
+		{
+			name:          "query parameter out of range",
+			method:        "GET",
+			target:        "/api/orders?limit=500",
+			wantOperation: "GET /orders",
+			wantViolations: []Violation{
+				{In: "query", Field: "limit", Message: "number must be at most 100"},
+			},
+		},
+		{
+			name:          "path parameter of the wrong type",
+			method:        "GET",
+			target:        "/api/orders/abc",
+			wantOperation: "getOrder",
+			wantViolations: []Violation{
+				{In: "path", Field: "id"},
+			},
+		},
+		{
+			name:      "valid body",
+			method:    "POST",
+			target:    "/api/orders",
+			headers:   map[string]string{"Content-Type": "application/json", "X-Tenant": "acme"},
+			body:      `{"item": "book", "quantity": 2}`,
+			wantValid: true,
+		},
+		{
+			name:          "invalid body and missing header",
+			method:        "POST",
+			target:        "/api/orders",
+			headers:       map[string]string{"Content-Type": "application/json"},
+			body:          `{"quantity": 0}`,
+			wantOperation: "createOrder",
+			wantViolations: []Violation{
+				{In: "header", Field: "X-Tenant", Message: "value is required but missing"},
+				{In: "body", Field: "/quantity", Message: "number must be at least 1"},
+				{In: "body", Field: "/item", Message: `property "item" is missing`},
+			},
+		},
+		{
+			name:          "unsupported content type",
+			method:        "POST",
+			target:        "/api/orders",
+			headers:       map[string]string{"Content-Type": "text/plain", "X-Tenant": "acme"},
+			body:          "book",
+			wantOperation: "createOrder",
+			wantViolations: []Violation{
+				{In: "body"},
+			},
+		},
+		{
+			name:   "undocumented path",
+			method: "GET",
+			target: "/api/customers",
+			wantViolations: []Violation{
+				{In: "route", Message: "no matching operation was found"},
+			},
+		},
+		{
+			name:   "undocumented method",
+			method: "DELETE",
+			target: "/api/orders",
+			wantViolations: []Violation{
+				{In: "route", Message: "method not allowed"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			report := v.Validate(req)
+			if tt.wantValid {
+				if report != nil {
+					t.Fatalf("unexpected report %+v", report)
+				}
+				return
+			}
+			if report == nil {
+				t.Fatal("request was accepted")
+			}
+			if report.Operation != tt.wantOperation {
+				t.Errorf("operation = %q, want %q", report.Operation, tt.wantOperation)
+			}
+			if len(report.Violations) != len(tt.wantViolations) {
+				t.Fatalf("violations = %+v, want %+v", report.Violations, tt.wantViolations)
+			}
+			for i, want := range tt.wantViolations {
+				got := report.Violations[i]
+				if got.In != want.In || got.Field != want.Field {
+					t.Errorf("violation %d = %+v, want %+v", i, got, want)
+				}
+				if want.Message != "" && got.Message != want.Message {
+					t.Errorf("violation %d message = %q, want %q", i, got.Message, want.Message)
+				}
+				if got.Message == "" {
+					t.Errorf("violation %d has no message", i)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorFor(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "orders.yaml")
+	if err := os.WriteFile(spec, []byte(ordersSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vc := &config.RequestValidationConfig{Spec: spec, BasePath: "/v2"}
+
+	first, err := ValidatorFor(vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := ValidatorFor(vc); again != first {
+		t.Error("validator was not cached")
+	}
+	if report := first.Validate(httptest.NewRequest(http.MethodGet, "/v2/orders/7", nil)); report != nil {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	// Changing the document reloads it
+	changed := strings.Replace(ordersSpec, "/orders/{id}:", "/invoices/{id}:", 1)
+	if err := os.WriteFile(spec, []byte(changed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	os.Chtimes(spec, later, later)
+	reloaded, err := ValidatorFor(vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := reloaded.Validate(httptest.NewRequest(http.MethodGet, "/v2/orders/7", nil)); report == nil || !report.Undocumented() {
+		t.Errorf("changed document not reloaded, report %+v", report)
+	}
+
+	if _, err := ValidatorFor(&config.RequestValidationConfig{Spec: filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("expected an error for a missing document")
+	}
+}
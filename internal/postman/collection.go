@@ -0,0 +1,76 @@
+package postman
+
+import "strings"
+
+// SchemaURL identifies the Postman collection format v2.1
+const SchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// BaseURLVariable is the collection variable request URLs start with, so the
+// target server can be changed in one place
+const BaseURLVariable = "baseUrl"
+
+// Collection is a Postman v2.1 collection
+type Collection struct {
+	Info     Info       `json:"info"`
+	Item     []Item     `json:"item"`
+	Variable []Variable `json:"variable,omitempty"`
+}
+
+// Info describes the collection
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// Item is a named request
+type Item struct {
+	Name    string  `json:"name"`
+	Request Request `json:"request"`
+}
+
+// Request is a request of an item
+type Request struct {
+	Method      string   `json:"method"`
+	Header      []Header `json:"header"`
+	URL         URL      `json:"url"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Header is a request header
+type Header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// URL is a request URL, Raw is what Postman shows and the other fields its
+// parsed form
+type URL struct {
+	Raw  string   `json:"raw"`
+	Host []string `json:"host"`
+	Path []string `json:"path"`
+}
+
+// Variable is a collection variable
+type Variable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// New creates an empty collection whose requests are relative to baseURL
+func New(name, baseURL string) *Collection {
+	return &Collection{
+		Info:     Info{Name: name, Schema: SchemaURL},
+		Item:     []Item{},
+		Variable: []Variable{{Key: BaseURLVariable, Value: baseURL}},
+	}
+}
+
+// NewURL builds a URL relative to the base URL variable
+func NewURL(path string) URL {
+	return URL{
+		Raw:  "{{" + BaseURLVariable + "}}" + path,
+		Host: []string{"{{" + BaseURLVariable + "}}"},
+		Path: strings.Split(strings.TrimPrefix(path, "/"), "/"),
+	}
+}
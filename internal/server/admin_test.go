@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+)
+
+func newMockedConfigPaths(t *testing.T) config.PathMatcher {
+	t.Helper()
+	pm := config.NewPathMatcher()
+	if err := pm.Add(&config.PathConfig{
+		Name:     "mocked-config",
+		Pattern:  "^/config$",
+		Response: config.ResponseConfig{StatusCode: http.StatusOK, Body: "mocked"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return pm
+}
+
+func TestAdminPrefixAndAuth(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{
+		PathMatcher: newMockedConfigPaths(t),
+		Admin: &config.AdminConfig{
+			Prefix:   "/__admin/",
+			Token:    "secret",
+			Username: "admin",
+			Password: "pw",
+		},
+	})
+	routes := setupRoutes(cm)
+	rejected := counter.GetGlobalCounter().GetAllStatusCounts()["401"]
+
+	tests := []struct {
+		name       string
+		target     string
+		auth       func(r *http.Request)
+		wantStatus int
+		wantBody   string
+	}{
+		{"mock owns /config", "/config", nil, http.StatusOK, "mocked"},
+		{"admin without credentials", "/__admin/config", nil, http.StatusUnauthorized, ""},
+		{"admin with wrong token", "/__admin/config", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer wrong")
+		}, http.StatusUnauthorized, ""},
+		{"admin with token", "/__admin/config", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer secret")
+		}, http.StatusOK, `"name":"mocked-config"`},
+		{"admin with basic auth", "/__admin/counter", func(r *http.Request) {
+			r.SetBasicAuth("admin", "pw")
+		}, http.StatusOK, `"global"`},
+		{"counter export before path counters", "/__admin/counter/export", func(r *http.Request) {
+			r.SetBasicAuth("admin", "pw")
+		}, http.StatusOK, `"time"`},
+		{"ui below prefix", "/__admin/ui/", func(r *http.Request) {
+			r.SetBasicAuth("admin", "pw")
+		}, http.StatusOK, `src="ui.js"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.auth != nil {
+				tt.auth(req)
+			}
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if got := counter.GetGlobalCounter().GetAllStatusCounts()["401"]; got != rejected {
+		t.Errorf("401 count = %d, want rejected admin requests not to be counted", got-rejected)
+	}
+}
+
+// Configs that skipped loader validation must still not open the admin API
+func TestAdminAuthIncompleteCredentials(t *testing.T) {
+	for _, admin := range []*config.AdminConfig{{Password: "pw"}, {Username: "admin"}} {
+		srv := startTestServer(t, &config.ServerConfig{
+			Host:        "127.0.0.1",
+			PathMatcher: config.NewPathMatcher(),
+			Admin:       admin,
+		})
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/config", srv.Addr()), nil)
+		req.SetBasicAuth(admin.Username, admin.Password)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%+v: GET /config = %d, want 401", admin, resp.StatusCode)
+		}
+	}
+}
+
+func TestAdminListener(t *testing.T) {
+	srv := startTestServer(t, &config.ServerConfig{
+		Host:        "127.0.0.1",
+		PathMatcher: newMockedConfigPaths(t),
+		Admin:       &config.AdminConfig{Port: freePort(t)},
+	})
+
+	get := func(addr net.Addr, path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", addr, path))
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if _, body := get(srv.Addr(), "/config"); body != "mocked" {
+		t.Errorf("main listener /config = %q, want the mock", body)
+	}
+
+	adminAddr := srv.ListenerAddr(adminListenerName)
+	if status, body := get(adminAddr, "/config"); status != http.StatusOK || !strings.Contains(body, "mocked-config") {
+		t.Errorf("admin listener /config = %d %q, want the path configs", status, body)
+	}
+	if status, _ := get(adminAddr, "/hello"); status != http.StatusNotFound {
+		t.Errorf("admin listener /hello = %d, want 404", status)
+	}
+}
+
+// freePort returns a port that was free a moment ago, for listeners where
+// port 0 has another meaning
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/counter"
+)
+
+func TestMultipleListeners(t *testing.T) {
+	mainPaths := config.NewPathMatcher()
+	if err := mainPaths.Add(&config.PathConfig{
+		Name:     "main-hello",
+		Pattern:  "^/hello$",
+		Response: config.ResponseConfig{Body: "main"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	payments := config.NewPathMatcher()
+	if err := payments.Add(&config.PathConfig{
+		Name:     "payments-hello",
+		Pattern:  "^/hello$",
+		Response: config.ResponseConfig{Body: "payments"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := startTestServer(t, &config.ServerConfig{
+		Host:        "127.0.0.1",
+		PathMatcher: mainPaths,
+		Listeners: []config.ListenerConfig{
+			{Name: "payments", PathMatcher: payments},
+			{Name: "shared"},
+		},
+	})
+
+	tests := []struct {
+		listener string
+		want     string
+	}{
+		{"main", "main"},
+		{"payments", "payments"},
+		{"shared", "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.listener, func(t *testing.T) {
+			addr := srv.ListenerAddr(tt.listener)
+			if addr == nil {
+				t.Fatalf("Listener %s is not bound", tt.listener)
+			}
+
+			resp, err := http.Get("http://" + addr.String() + "/hello")
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if got := strings.TrimSpace(string(body)); got != tt.want {
+				t.Errorf("Body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadListenerConfigs(t *testing.T) {
+	loader := config.NewLoader()
+	cfg := loader.GetConfig()
+	cfg.Listeners = []config.ListenerConfig{
+		{
+			Name:  "inline",
+			Paths: []config.PathConfig{{Name: "a", Pattern: "^/a$"}},
+		},
+		{Name: "shared"},
+	}
+
+	if err := loader.LoadListenerConfigs(); err != nil {
+		t.Fatalf("LoadListenerConfigs failed: %v", err)
+	}
+
+	if cfg.Listeners[0].PathMatcher == nil {
+		t.Fatal("Expected inline listener to get its own PathMatcher")
+	}
+	if _, ok := cfg.Listeners[0].PathMatcher.Match("/a", "GET"); !ok {
+		t.Error("Expected inline path to match")
+	}
+	if cfg.Listeners[1].PathMatcher != nil {
+		t.Error("Expected listener without paths to share the main PathMatcher")
+	}
+}
+
+func TestExcludeAdminFromCounters(t *testing.T) {
+	c := counter.GetGlobalCounter()
+
+	tests := []struct {
+		name    string
+		exclude bool
+		want    uint64
+	}{
+		{"admin counted by default", false, 1},
+		{"admin excluded", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.Reset()
+			t.Cleanup(c.Reset)
+
+			cm := config.NewConfigManager()
+			cm.UpdateConfig(&config.ServerConfig{
+				PathMatcher:              config.NewPathMatcher(),
+				ExcludeAdminFromCounters: tt.exclude,
+			})
+			routes := setupRoutes(cm)
+
+			routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/counter", nil))
+			if got := c.GetPathCount("/counter"); got != tt.want {
+				t.Errorf("/counter count = %d, want %d", got, tt.want)
+			}
+
+			// Mocked traffic is always counted
+			routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/mocked", nil))
+			if got := c.GetPathCount("/mocked"); got != 1 {
+				t.Errorf("/mocked count = %d, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/handler"
+	"echo-server/internal/namespace"
+)
+
+func TestNamespaces(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{PathMatcher: newMockedConfigPaths(t), Admin: &config.AdminConfig{Prefix: "/_admin"}})
+	routes := setupRoutes(cm)
+	t.Cleanup(func() {
+		for _, ns := range namespace.Default().List() {
+			namespace.Default().Delete(ns.Name)
+		}
+	})
+
+	do := func(method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		routes.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for _, name := range []string{"suite-a", "suite-b"} {
+		if rr := do(http.MethodPost, "/_admin/namespaces", `{"name": "`+name+`", "ttl": "10m"}`, nil); rr.Code != http.StatusCreated {
+			t.Fatalf("create %s = %d: %s", name, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := do(http.MethodPost, "/_admin/namespaces", `{"name": "suite-a"}`, nil); rr.Code != http.StatusConflict {
+		t.Errorf("duplicate create = %d, want 409", rr.Code)
+	}
+
+	// The same path is stubbed differently in each namespace, by header and
+	// by path prefix
+	stub := func(body string) string {
+		return `{"name": "users", "pattern": "^/users$", "response": {"statusCode": 200, "body": "` + body + `"}}`
+	}
+	if rr := do(http.MethodPost, "/_admin/config/paths", stub("a"), map[string]string{namespace.Header: "suite-a"}); rr.Code != http.StatusCreated {
+		t.Fatalf("stub in suite-a = %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/ns/suite-b/_admin/config/paths", stub("b"), nil); rr.Code != http.StatusCreated {
+		t.Fatalf("stub in suite-b = %d: %s", rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		header   map[string]string
+		wantCode int
+		wantBody string
+	}{
+		{name: "namespace a by header", target: "/users", header: map[string]string{namespace.Header: "suite-a"}, wantCode: http.StatusOK, wantBody: "a"},
+		{name: "namespace b by prefix", target: "/ns/suite-b/users", wantCode: http.StatusOK, wantBody: "b"},
+		{name: "server paths are not visible in namespaces", target: "/ns/suite-a/config", wantCode: http.StatusOK, wantBody: `"path":"/config"`},
+		{name: "server unaffected", target: "/config", wantCode: http.StatusOK, wantBody: "mocked"},
+		{name: "unknown namespace header", target: "/users", header: map[string]string{namespace.Header: "missing"}, wantCode: http.StatusNotFound, wantBody: "unknown namespace"},
+		{name: "unknown prefix is a normal path", target: "/ns/missing/users", wantCode: http.StatusOK, wantBody: `"path":"/ns/missing/users"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := do(http.MethodGet, tt.target, "", tt.header)
+			if rr.Code != tt.wantCode || !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("%s = %d %q, want %d containing %q", tt.target, rr.Code, rr.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+
+	// Counters and journals are kept per namespace
+	var counters handler.CounterResponse
+	json.NewDecoder(do(http.MethodGet, "/ns/suite-a/_admin/counter", "", nil).Body).Decode(&counters)
+	if counters.Paths["/users"] != 1 || counters.Paths["/config"] != 1 {
+		t.Errorf("suite-a counters = %+v", counters.Paths)
+	}
+	var requests handler.RequestListResponse
+	json.NewDecoder(do(http.MethodGet, "/_admin/requests", "", map[string]string{namespace.Header: "suite-b"}).Body).Decode(&requests)
+	if requests.Total != 1 || requests.Requests[0].Path != "/users" {
+		t.Errorf("suite-b journal = %+v", requests)
+	}
+
+	var infos []namespace.Info
+	json.NewDecoder(do(http.MethodGet, "/_admin/namespaces", "", nil).Body).Decode(&infos)
+	if len(infos) != 2 || infos[0].Name != "suite-a" || infos[0].Configs != 1 || infos[0].ExpiresAt == nil {
+		t.Errorf("namespaces = %+v", infos)
+	}
+
+	if rr := do(http.MethodDelete, "/_admin/namespaces/suite-a", "", nil); rr.Code != http.StatusNoContent {
+		t.Errorf("delete = %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/users", "", map[string]string{namespace.Header: "suite-a"}); rr.Code != http.StatusNotFound {
+		t.Errorf("deleted namespace still answers: %d", rr.Code)
+	}
+}
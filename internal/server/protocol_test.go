@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"echo-server/internal/config"
+	"echo-server/internal/model"
+)
+
+func getEcho(t *testing.T, client *http.Client, url string) *model.RequestData {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var data model.RequestData
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		t.Fatalf("Failed to decode echo: %v", err)
+	}
+	return &data
+}
+
+func TestH2C(t *testing.T) {
+	srv := startTestServer(t, &config.ServerConfig{
+		Host:        "127.0.0.1",
+		PathMatcher: config.NewPathMatcher(),
+	})
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	url := "http://" + srv.Addr().String() + "/h2c"
+
+	first := getEcho(t, client, url)
+	second := getEcho(t, client, url)
+
+	if first.Protocol != "HTTP/2.0" {
+		t.Errorf("Protocol = %q, want HTTP/2.0", first.Protocol)
+	}
+	if first.Connection == nil || second.Connection == nil {
+		t.Fatal("Expected connection details in echo")
+	}
+	if first.Connection.Reused || first.Connection.RequestNumber != 1 {
+		t.Errorf("First request reused = %v, number = %d", first.Connection.Reused, first.Connection.RequestNumber)
+	}
+	if !second.Connection.Reused || second.Connection.RequestNumber != 2 {
+		t.Errorf("Second request reused = %v, number = %d", second.Connection.Reused, second.Connection.RequestNumber)
+	}
+}
+
+func TestHTTP2OverTLS(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	srv := startTestServer(t, &config.ServerConfig{
+		Host:        "127.0.0.1",
+		PathMatcher: config.NewPathMatcher(),
+		TLS:         &config.TLSConfig{SelfSigned: true, CAOutFile: caFile},
+	})
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+	data := getEcho(t, client, "https://"+srv.Addr().String()+"/h2")
+
+	if data.Protocol != "HTTP/2.0" {
+		t.Errorf("Protocol = %q, want HTTP/2.0", data.Protocol)
+	}
+	if data.TLS == nil {
+		t.Fatal("Expected TLS details in echo")
+	}
+	if data.TLS.NegotiatedProtocol != "h2" || data.TLS.Version != "TLS 1.3" || data.TLS.CipherSuite == "" {
+		t.Errorf("TLS details = %+v", data.TLS)
+	}
+}
+
+func TestDisableHTTP2(t *testing.T) {
+	srv := startTestServer(t, &config.ServerConfig{
+		Host:         "127.0.0.1",
+		PathMatcher:  config.NewPathMatcher(),
+		DisableHTTP2: true,
+	})
+
+	data := getEcho(t, http.DefaultClient, "http://"+srv.Addr().String()+"/h1")
+	if data.Protocol != "HTTP/1.1" {
+		t.Errorf("Protocol = %q, want HTTP/1.1", data.Protocol)
+	}
+	if data.Connection == nil || data.Connection.RequestNumber != 1 {
+		t.Errorf("Connection = %+v, want the first request on the connection", data.Connection)
+	}
+}
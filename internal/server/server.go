@@ -2,73 +2,321 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
 
 	"echo-server/internal/config"
 	"echo-server/internal/handler"
 	"echo-server/internal/middleware"
+	"echo-server/internal/model"
+	"echo-server/internal/namespace"
 	"echo-server/pkg/logger"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	mainListenerName  = "main"
+	unixListenerName  = "unix"
+	adminListenerName = "admin"
+)
+
 type Server struct {
 	configManager *config.ConfigManager
-	server        *http.Server
+	listeners     []*listener
 	mu            sync.RWMutex
+}
+
+// listener is one bound address with its own routes and configuration
+type listener struct {
+	name          string
+	network       string
+	address       string
+	socketMode    string
+	tls           *config.TLSConfig
+	configManager *config.ConfigManager
 	handler       http.Handler
+	server        *http.Server
+	ln            net.Listener
 }
 
 func New(configManager *config.ConfigManager) *Server {
-	return &Server{
-		configManager: configManager,
-		handler:       setupRoutes(configManager),
+	cfg := configManager.GetConfig()
+
+	s := &Server{configManager: configManager}
+	s.listeners = append(s.listeners, newListener(mainListenerName, configManager, config.ListenerConfig{
+		Host: cfg.Host,
+		Port: cfg.Port,
+	}))
+
+	if cfg.UnixSocket != "" {
+		unix := newListener(unixListenerName, configManager, config.ListenerConfig{
+			UnixSocket: cfg.UnixSocket,
+			SocketMode: cfg.SocketMode,
+		})
+		// The main TLS settings belong to the TCP listener, clients of the
+		// socket speak plain HTTP
+		unix.tls = nil
+		s.listeners = append(s.listeners, unix)
 	}
+
+	if cfg.Admin != nil && cfg.Admin.Port != 0 {
+		host := cfg.Admin.Host
+		if host == "" {
+			host = cfg.Host
+		}
+		s.listeners = append(s.listeners, &listener{
+			name:          adminListenerName,
+			network:       "tcp",
+			address:       fmt.Sprintf("%s:%d", host, cfg.Admin.Port),
+			tls:           cfg.TLS,
+			configManager: configManager,
+			handler:       setupAdminRoutes(configManager),
+		})
+	}
+
+	for i, l := range cfg.Listeners {
+		name := l.Name
+		if name == "" {
+			name = fmt.Sprintf("listener-%d", i+1)
+		}
+		// Listeners without their own paths share the main PathMatcher
+		cm := config.NewConfigManager()
+		cm.UpdateConfig(cfg.ForListener(l))
+		s.listeners = append(s.listeners, newListener(name, cm, l))
+	}
+	return s
+}
+
+func newListener(name string, cm *config.ConfigManager, l config.ListenerConfig) *listener {
+	result := &listener{
+		name:          name,
+		network:       "tcp",
+		address:       fmt.Sprintf("%s:%d", cm.GetConfig().Host, l.Port),
+		tls:           cm.GetConfig().TLS,
+		configManager: cm,
+		handler:       setupRoutes(cm),
+	}
+	if l.UnixSocket != "" {
+		result.network = "unix"
+		result.address = l.UnixSocket
+		result.socketMode = l.SocketMode
+	}
+	return result
 }
 
 func setupRoutes(configManager *config.ConfigManager) http.Handler {
 	routes := mux.NewRouter()
 
+	// A dedicated admin listener takes the admin endpoints off this one
+	if admin := configManager.GetConfig().Admin; admin == nil || admin.Port == 0 {
+		registerAdminRoutes(routes, configManager)
+	}
+
+	// Main echo handler with logging middleware for all other paths
+	routes.PathPrefix("/").Handler(middleware.RequestLogging(handler.NewEchoHandler(configManager.GetConfig())))
+
+	return middleware.Namespaces(routes, namespace.Default())
+}
+
+// setupAdminRoutes serves only the admin endpoints, for the admin listener
+func setupAdminRoutes(configManager *config.ConfigManager) http.Handler {
+	routes := mux.NewRouter()
+	registerAdminRoutes(routes, configManager)
+	return middleware.Namespaces(routes, namespace.Default())
+}
+
+// registerAdminRoutes adds the admin endpoints below the configured prefix.
+// Handlers see paths with the prefix stripped.
+func registerAdminRoutes(routes *mux.Router, configManager *config.ConfigManager) {
+	cfg := configManager.GetConfig()
+	var prefix string
+	if cfg.Admin != nil {
+		prefix = strings.TrimSuffix(cfg.Admin.Prefix, "/")
+	}
+	counted := !cfg.ExcludeAdminFromCounters
+	// Rejected requests are neither counted nor logged as admin requests
+	admin := func(h http.Handler) http.Handler {
+		h = middleware.AdminRequestLogging(http.StripPrefix(prefix, h), counted)
+		return middleware.AdminAuth(h, cfg.Admin)
+	}
+
 	// Configuration endpoints
 	configHandler := handler.NewConfigurationHandler(configManager)
-	routes.PathPrefix("/config").Handler(middleware.RequestLogging(configHandler))
+	routes.PathPrefix(prefix + "/config").Handler(admin(configHandler))
 
-	// Counter endpoint with logging middleware
-	routes.Handle("/counter", middleware.RequestLogging(http.HandlerFunc(handler.CounterHandler)))
+	// Counter endpoints, /counter/{path} addresses the counter of a single path.
+	// Export and import are registered first so they take precedence; the
+	// counters of mocked paths /export and /import are still listed by
+	// GET /counter and reset with DELETE /counter?path=.
+	routes.Handle(prefix+"/counter/export", admin(http.HandlerFunc(handler.CounterExportHandler)))
+	routes.Handle(prefix+"/counter/import", admin(http.HandlerFunc(handler.CounterImportHandler)))
+	routes.Handle(prefix+"/counter-snapshot", admin(http.HandlerFunc(handler.CounterSnapshotHandler)))
+	routes.Handle(prefix+"/counter", admin(http.HandlerFunc(handler.CounterHandler)))
+	routes.PathPrefix(prefix + "/counter/").Handler(admin(http.HandlerFunc(handler.CounterHandler)))
 
-	// Main echo handler with logging middleware for all other paths
-	uiHandler := handler.NewUIHandler(configManager)
-	routes.Handle("/ui/", middleware.RequestLogging(uiHandler))
-	routes.PathPrefix("/ui/").Handler(middleware.RequestLogging(uiHandler))
-	routes.Handle("/ui", http.RedirectHandler("/ui/", http.StatusPermanentRedirect))
-	routes.PathPrefix("/").Handler(middleware.RequestLogging(handler.NewEchoHandler(configManager.GetConfig())))
+	// Requests no path config matched
+	routes.Handle(prefix+"/unmatched", admin(http.HandlerFunc(handler.UnmatchedHandler)))
+
+	// Journal of requests answered by the mocks
+	routes.Handle(prefix+"/requests", admin(http.HandlerFunc(handler.RequestsHandler)))
+	routes.Handle(prefix+"/requests/har", admin(http.HandlerFunc(handler.RequestsHandler)))
 
-	return routes
+	// Isolated sets of path configs, counters and journals
+	routes.PathPrefix(prefix + "/namespaces").Handler(admin(handler.NewNamespaceHandler(configManager, namespace.Default())))
+
+	// Runtime administration
+	routes.Handle(prefix+"/admin/log-level", admin(http.HandlerFunc(handler.LogLevelHandler)))
+
+	// Web UI
+	uiHandler := handler.NewUIHandler(configManager)
+	routes.PathPrefix(prefix + "/ui/").Handler(admin(uiHandler))
+	routes.Handle(prefix+"/ui", http.RedirectHandler(prefix+"/ui/", http.StatusPermanentRedirect))
 }
 
+// Start listens on all configured addresses and serves until the server is stopped
 func (s *Server) Start() error {
-	cfg := s.configManager.GetConfig()
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	return s.Serve()
+}
 
+// Listen binds every listener and prepares its http.Server without serving
+// yet. Listeners bound before a failure are closed again.
+func (s *Server) Listen() error {
 	s.mu.Lock()
-	s.server = &http.Server{
-		Addr:         addr,
-		Handler:      s.handler,
+	defer s.mu.Unlock()
+
+	for i, l := range s.listeners {
+		if err := l.listen(); err != nil {
+			for _, bound := range s.listeners[:i] {
+				bound.ln.Close()
+			}
+			return fmt.Errorf("listener %s: %w", l.name, err)
+		}
+	}
+	return nil
+}
+
+func (l *listener) listen() error {
+	cfg := l.configManager.GetConfig()
+
+	srv := &http.Server{
+		Addr:         l.address,
+		Handler:      middleware.ConnectionTracking(middleware.Tracing(l.handler)),
 		ReadTimeout:  cfg.ReadTimeout.Duration,
 		WriteTimeout: cfg.WriteTimeout.Duration,
+		ConnContext:  model.NewConnContext,
+		Protocols:    newProtocols(cfg),
 	}
-	s.mu.Unlock()
 
-	logger.Info("Starting server on %s", addr)
-	return s.server.ListenAndServe()
+	if l.tls != nil {
+		tlsConfig, err := newTLSConfig(l.tls)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsConfig
+	}
+
+	var ln net.Listener
+	var err error
+	if l.network == "unix" {
+		ln, err = listenUnix(l.address, l.socketMode)
+	} else {
+		ln, err = net.Listen(l.network, l.address)
+	}
+	if err != nil {
+		return err
+	}
+
+	l.server = srv
+	l.ln = ln
+	return nil
 }
 
-func (s *Server) Stop(ctx context.Context) error {
-	if s.server != nil {
-		logger.Info("Shutting down server...")
-		return s.server.Shutdown(ctx)
+func newProtocols(cfg *config.ServerConfig) *http.Protocols {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if !cfg.DisableHTTP2 {
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	return protocols
+}
+
+// Serve accepts connections on every listener bound by Listen. It returns
+// when the first listener stops, http.ErrServerClosed after Stop.
+func (s *Server) Serve() error {
+	s.mu.RLock()
+	listeners := s.listeners
+	s.mu.RUnlock()
+
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l *listener) {
+			errs <- l.serve()
+		}(l)
+	}
+
+	err := <-errs
+	if !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Listener failed, shutting down: %v", err)
+		s.Stop(context.Background())
+	}
+	return err
+}
+
+func (l *listener) serve() error {
+	scheme := "http"
+	if l.server.TLSConfig != nil {
+		scheme = "https"
+	}
+	if l.network == "unix" {
+		scheme += "+unix"
+	}
+	logger.Info("Starting %s listener on %s://%s", l.name, scheme, l.ln.Addr())
+
+	if l.server.TLSConfig != nil {
+		// Certificates are already in TLSConfig
+		return l.server.ServeTLS(l.ln, "", "")
+	}
+	return l.server.Serve(l.ln)
+}
+
+// Addr returns the address of the main listener, nil before Listen
+func (s *Server) Addr() net.Addr {
+	return s.ListenerAddr(mainListenerName)
+}
+
+// ListenerAddr returns the address of the named listener, nil when the
+// listener does not exist or is not bound yet
+func (s *Server) ListenerAddr(name string) net.Addr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, l := range s.listeners {
+		if l.name == name && l.ln != nil {
+			return l.ln.Addr()
+		}
 	}
 	return nil
 }
+
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var errs []error
+	for _, l := range s.listeners {
+		if l.server != nil {
+			logger.Info("Shutting down %s listener...", l.name)
+			errs = append(errs, l.server.Shutdown(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/pkg/logger"
+)
+
+const defaultCAOutFile = "echo-server-ca.pem"
+
+var defaultCertHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// newTLSConfig builds the listener TLS configuration from the server config
+func newTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	var cert tls.Certificate
+	var err error
+
+	switch {
+	case cfg.CertFile != "" || cfg.KeyFile != "":
+		cert, err = tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading TLS certificate: %w", err)
+		}
+	case cfg.SelfSigned:
+		caOutFile := cfg.CAOutFile
+		if caOutFile == "" {
+			caOutFile = defaultCAOutFile
+		}
+		cert, err = generateSelfSigned(cfg.Hosts, caOutFile)
+		if err != nil {
+			return nil, fmt.Errorf("generating self-signed certificate: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("tls requires certFile and keyFile or selfSigned")
+	}
+
+	clientAuth, err := parseClientAuth(cfg.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   clientAuth,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pemData, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+	} else if clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert {
+		return nil, fmt.Errorf("clientAuth %q requires clientCAFile", cfg.ClientAuth)
+	}
+
+	return tlsConfig, nil
+}
+
+func parseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require-verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("invalid clientAuth: %s", mode)
+	}
+}
+
+// generateSelfSigned creates a throwaway CA and a server certificate signed by
+// it. Only the CA certificate is written to disk, the keys stay in memory.
+func generateSelfSigned(hosts []string, caOutFile string) (tls.Certificate, error) {
+	if len(hosts) == 0 {
+		hosts = defaultCertHosts
+	}
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(365 * 24 * time.Hour)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "echo-server CA", Organization: []string{"echo-server"}},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: hosts[0], Organization: []string{"echo-server"}},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if dir := filepath.Dir(caOutFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	if err := os.WriteFile(caOutFile, caPEM, 0644); err != nil {
+		return tls.Certificate{}, fmt.Errorf("writing CA certificate: %w", err)
+	}
+	logger.Info("Generated self-signed certificate for %v, CA written to %s", hosts, caOutFile)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der, caDER},
+		PrivateKey:  key,
+	}, nil
+}
+
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"echo-server/internal/config"
+	"echo-server/internal/model"
+)
+
+// newClientCertificate creates a CA, writes it to dir and returns a client
+// certificate signed by it
+func newClientCertificate(t *testing.T, dir string) (tls.Certificate, string) {
+	t.Helper()
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "test client CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:   randomSerial(),
+		Subject:        pkix.Name{CommonName: "test-client"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		DNSNames:       []string{"client.test"},
+		EmailAddresses: []string{"client@example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caFile := filepath.Join(dir, "client-ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+func startTestServer(t *testing.T, cfg *config.ServerConfig) *Server {
+	t.Helper()
+
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(cfg)
+	srv := New(cm)
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go srv.Serve()
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+	return srv
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, clientCAFile := newClientCertificate(t, dir)
+	serverCAFile := filepath.Join(dir, "server-ca.pem")
+
+	srv := startTestServer(t, &config.ServerConfig{
+		Host:        "127.0.0.1",
+		PathMatcher: config.NewPathMatcher(),
+		TLS: &config.TLSConfig{
+			SelfSigned:   true,
+			CAOutFile:    serverCAFile,
+			ClientAuth:   "require-verify",
+			ClientCAFile: clientCAFile,
+		},
+	})
+
+	caPEM, err := os.ReadFile(serverCAFile)
+	if err != nil {
+		t.Fatalf("CA certificate was not written: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+
+	url := "https://" + srv.Addr().String() + "/mtls"
+
+	t.Run("without client certificate", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots},
+		}}
+		if resp, err := client.Get(url); err == nil {
+			resp.Body.Close()
+			t.Error("Expected handshake to fail without a client certificate")
+		}
+	})
+
+	t.Run("with client certificate", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}},
+		}}
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var data model.RequestData
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			t.Fatalf("Failed to decode echo: %v", err)
+		}
+		if data.TLS == nil || data.TLS.ClientCertificate == nil {
+			t.Fatal("Expected client certificate in echo")
+		}
+		cert := data.TLS.ClientCertificate
+		if cert.Subject != "CN=test-client" {
+			t.Errorf("Subject = %q, want %q", cert.Subject, "CN=test-client")
+		}
+		if len(cert.DNSNames) != 1 || cert.DNSNames[0] != "client.test" {
+			t.Errorf("DNSNames = %v, want [client.test]", cert.DNSNames)
+		}
+		if !cert.Verified || len(cert.Fingerprint) != 64 {
+			t.Errorf("Verified = %v, fingerprint = %q", cert.Verified, cert.Fingerprint)
+		}
+	})
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.TLSConfig
+	}{
+		{"no certificate source", config.TLSConfig{}},
+		{"missing key file", config.TLSConfig{CertFile: "does-not-exist.pem"}},
+		{"invalid client auth", config.TLSConfig{SelfSigned: true, ClientAuth: "sometimes"}},
+		{"verify without CA", config.TLSConfig{SelfSigned: true, ClientAuth: "require-verify"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.CAOutFile = filepath.Join(t.TempDir(), "ca.pem")
+			if _, err := newTLSConfig(&tt.cfg); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+
+	"echo-server/pkg/logger"
+)
+
+// listenUnix binds a Unix socket, replacing a stale socket left behind by a
+// previous run. A socket that still accepts connections belongs to a running
+// server and is left alone. The socket file is removed again when the
+// listener closes.
+func listenUnix(path, mode string) (net.Listener, error) {
+	var perm os.FileMode
+	if mode != "" {
+		parsed, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid socketMode %q: %w", mode, err)
+		}
+		perm = os.FileMode(parsed)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s: address in use", path)
+		}
+		if !errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("%s: address in use: %w", path, err)
+		}
+		logger.Warn("Removing stale socket %s", path)
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(true)
+
+	if mode != "" {
+		if err := os.Chmod(path, perm); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("setting socket permissions: %w", err)
+		}
+	}
+	return ln, nil
+}
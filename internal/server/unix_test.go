@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"echo-server/internal/config"
+)
+
+func TestUnixSocketListener(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "echo.sock")
+
+	// A stale socket from a previous run must not prevent startup
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	cm := config.NewConfigManager()
+	cm.UpdateConfig(&config.ServerConfig{
+		Host:        "127.0.0.1",
+		PathMatcher: config.NewPathMatcher(),
+		UnixSocket:  socketPath,
+		SocketMode:  "0600",
+	})
+	srv := New(cm)
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go srv.Serve()
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("Socket not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Socket mode = %o, want 600", perm)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	data := getEcho(t, client, "http://unix/socket")
+	if data.Path != "/socket" {
+		t.Errorf("Path = %q, want /socket", data.Path)
+	}
+
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("Socket was not removed on shutdown: %v", err)
+	}
+}
+
+func TestUnixSocketRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if ln, err := listenUnix(path, ""); err == nil {
+		ln.Close()
+		t.Error("Expected an error when the path is a regular file")
+	}
+}
+
+func TestUnixSocketRefusesLiveSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "live.sock")
+	live, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+
+	if ln, err := listenUnix(path, ""); err == nil {
+		ln.Close()
+		t.Fatal("Expected an error when another server listens on the socket")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Live socket was removed: %v", err)
+	}
+}
+
+func TestUnixSocketWithoutMainTLS(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "echo.sock")
+	srv := startTestServer(t, &config.ServerConfig{
+		Host:        "127.0.0.1",
+		PathMatcher: config.NewPathMatcher(),
+		UnixSocket:  socketPath,
+		TLS:         &config.TLSConfig{SelfSigned: true, CAOutFile: filepath.Join(dir, "ca.pem")},
+	})
+	if srv.Addr() == nil {
+		t.Fatal("main listener not bound")
+	}
+
+	// Plain HTTP, like curl --unix-socket
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	data := getEcho(t, client, "http://unix/plain")
+	if data.Path != "/plain" || data.TLS != nil {
+		t.Errorf("echo = %s %+v, want /plain without TLS", data.Path, data.TLS)
+	}
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"echo-server/internal/config"
+	"echo-server/pkg/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "echo-server"
+	defaultServiceName  = "echo-server"
+
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+func init() {
+	// Propagate W3C trace context even when no exporter is configured so
+	// the proxy keeps traces connected through the echo server
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// Tracer returns the tracer used for echo server spans. Spans are no-ops
+// until Setup installs a tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs a global tracer provider exporting spans as configured. The
+// returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg *config.TracingConfig) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch cfg.Exporter {
+	case "", ExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s exporter: %w", cfg.Exporter, err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Info("Tracing enabled (exporter: %s, service: %s)", exporterName(cfg.Exporter), serviceName)
+
+	return provider.Shutdown, nil
+}
+
+func exporterName(exporter string) string {
+	if exporter == "" {
+		return ExporterOTLP
+	}
+	return exporter
+}
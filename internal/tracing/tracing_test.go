@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"echo-server/internal/config"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Setup(context.Background(), &config.TracingConfig{Exporter: ExporterStdout})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+
+	if _, err := Setup(context.Background(), &config.TracingConfig{Exporter: "zipkin"}); err == nil {
+		t.Error("Expected an error for an unknown exporter")
+	}
+}
@@ -1,27 +1,73 @@
 package logger
 
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
 var (
-	defaultLogger = New(DEBUG)
+	defaultLogger atomic.Pointer[Logger]
 )
 
+func init() {
+	defaultLogger.Store(New(DEBUG))
+}
+
+// Default returns the process wide logger
+func Default() *Logger {
+	return defaultLogger.Load()
+}
+
 // Global logger functions
 func Debug(format string, v ...interface{}) {
-	defaultLogger.Debug(format, v...)
+	Default().Debug(format, v...)
 }
 
 func Info(format string, v ...interface{}) {
-	defaultLogger.Info(format, v...)
+	Default().Info(format, v...)
 }
 
 func Warn(format string, v ...interface{}) {
-	defaultLogger.Warn(format, v...)
+	Default().Warn(format, v...)
 }
 
 func Error(format string, v ...interface{}) {
-	defaultLogger.Error(format, v...)
+	Default().Error(format, v...)
 }
 
 // SetLevel sets the logging level for the default logger
 func SetLevel(level Level) {
-	defaultLogger.level = level
+	Default().SetLevel(level)
+}
+
+// GetLevel returns the logging level of the default logger
+func GetLevel() Level {
+	return levelFromSlog(Default().level.Level())
+}
+
+// SetFormat replaces the default logger with one writing format (text, json
+// or logfmt) to w, keeping the current level. Meant to be called at startup.
+func SetFormat(format string, w io.Writer) error {
+	l, err := NewWithFormat(GetLevel(), format, w)
+	if err != nil {
+		return err
+	}
+	defaultLogger.Store(l)
+	return nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying l, used for request scoped fields
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return Default()
 }
@@ -1,9 +1,17 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Level int
@@ -15,48 +23,240 @@ const (
 	ERROR
 )
 
+// Output formats
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case DEBUG:
+		return slog.LevelDebug
+	case WARN:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func levelFromSlog(level slog.Level) Level {
+	switch {
+	case level <= slog.LevelDebug:
+		return DEBUG
+	case level <= slog.LevelInfo:
+		return INFO
+	case level <= slog.LevelWarn:
+		return WARN
+	default:
+		return ERROR
+	}
+}
+
+// ParseLevel converts a level name (debug, info, warn, error) to a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return DEBUG, nil
+	case "info":
+		return INFO, nil
+	case "warn", "warning":
+		return WARN, nil
+	case "error":
+		return ERROR, nil
+	default:
+		return INFO, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+func (l Level) String() string {
+	return l.slogLevel().String()
+}
+
+// Name returns the lower case level name accepted by ParseLevel
+func (l Level) Name() string {
+	return strings.ToLower(l.String())
+}
+
+// Logger writes printf style messages and structured fields through slog.
+// Loggers derived with With share the level of their parent.
 type Logger struct {
-	logger *log.Logger
-	level  Level
-	mu     sync.Mutex
+	slog  *slog.Logger
+	level *slog.LevelVar
 }
 
-// New creates a new Logger instance
+// New creates a new Logger instance writing text lines to stdout
 func New(level Level) *Logger {
-	return &Logger{
-		logger: log.New(os.Stdout, "", log.LstdFlags),
-		level:  level,
+	l, _ := NewWithFormat(level, FormatText, os.Stdout)
+	return l
+}
+
+// NewWithFormat creates a Logger writing the given format (text, json or
+// logfmt) to w
+func NewWithFormat(level Level, format string, w io.Writer) (*Logger, error) {
+	// Handlers accept everything, filtering happens in Logger so derived
+	// loggers can use a different level
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		handler = newTextHandler(w)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatLogfmt:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
 	}
+
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level.slogLevel())
+	return &Logger{slog: slog.New(handler), level: levelVar}, nil
 }
 
-func (l *Logger) Debug(format string, v ...interface{}) {
-	if l.level <= DEBUG {
-		l.mu.Lock()
-		l.logger.Printf("[DEBUG] "+format, v...)
-		l.mu.Unlock()
+// With returns a Logger that adds the given key/value pairs to every line
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{slog: l.slog.With(args...), level: l.level}
+}
+
+// WithLevel returns a Logger with the same fields but its own level, not
+// affected by later level changes of its parent
+func (l *Logger) WithLevel(level Level) *Logger {
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level.slogLevel())
+	return &Logger{slog: l.slog, level: levelVar}
+}
+
+// SetLevel changes the level of this logger and every logger derived from it
+func (l *Logger) SetLevel(level Level) {
+	l.level.Set(level.slogLevel())
+}
+
+func (l *Logger) enabled(level slog.Level) bool {
+	return level >= l.level.Level()
+}
+
+func (l *Logger) logf(level slog.Level, format string, v ...interface{}) {
+	if l.enabled(level) {
+		l.slog.Log(context.Background(), level, fmt.Sprintf(format, v...))
 	}
 }
 
-func (l *Logger) Info(format string, v ...interface{}) {
-	if l.level <= INFO {
-		l.mu.Lock()
-		l.logger.Printf("[INFO] "+format, v...)
-		l.mu.Unlock()
+// Log writes msg with structured key/value fields
+func (l *Logger) Log(level Level, msg string, args ...any) {
+	if l.enabled(level.slogLevel()) {
+		l.slog.Log(context.Background(), level.slogLevel(), msg, args...)
 	}
 }
 
+func (l *Logger) Debug(format string, v ...interface{}) {
+	l.logf(slog.LevelDebug, format, v...)
+}
+
+func (l *Logger) Info(format string, v ...interface{}) {
+	l.logf(slog.LevelInfo, format, v...)
+}
+
 func (l *Logger) Warn(format string, v ...interface{}) {
-	if l.level <= WARN {
-		l.mu.Lock()
-		l.logger.Printf("[WARN] "+format, v...)
-		l.mu.Unlock()
-	}
+	l.logf(slog.LevelWarn, format, v...)
 }
 
 func (l *Logger) Error(format string, v ...interface{}) {
-	if l.level <= ERROR {
-		l.mu.Lock()
-		l.logger.Printf("[ERROR] "+format, v...)
-		l.mu.Unlock()
+	l.logf(slog.LevelError, format, v...)
+}
+
+// textHandler keeps the original line format: a timestamp, the level in
+// brackets and the message, followed by any fields as key=value
+type textHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	attrs  []slog.Attr
+	prefix string
+}
+
+func newTextHandler(w io.Writer) *textHandler {
+	return &textHandler{w: w, mu: &sync.Mutex{}}
+}
+
+func (h *textHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	b.WriteString(t.Format("2006/01/02 15:04:05"))
+	b.WriteString(" [")
+	b.WriteString(r.Level.String())
+	b.WriteString("] ")
+	b.WriteString(escapeControl(r.Message))
+
+	for _, attr := range h.attrs {
+		writeTextAttr(&b, "", attr)
 	}
+	r.Attrs(func(attr slog.Attr) bool {
+		writeTextAttr(&b, h.prefix, attr)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	clone.attrs = append(clone.attrs, h.attrs...)
+	for _, attr := range attrs {
+		attr.Key = h.prefix + attr.Key
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return &clone
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func writeTextAttr(b *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		for _, inner := range attr.Value.Group() {
+			writeTextAttr(b, prefix+attr.Key+".", inner)
+		}
+		return
+	}
+	value := attr.Value.String()
+	if strings.ContainsAny(value, " \"=") || hasControl(value) {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, attr.Key, value)
+}
+
+// escapeControl escapes control characters such as line breaks, so values
+// taken from requests cannot forge log lines
+func escapeControl(s string) string {
+	if !hasControl(s) {
+		return s
+	}
+	quoted := strconv.Quote(s)
+	return quoted[1 : len(quoted)-1]
+}
+
+func hasControl(s string) bool {
+	return strings.ContainsFunc(s, func(r rune) bool {
+		return r == utf8.RuneError || (r != ' ' && !unicode.IsPrint(r))
+	})
 }
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(t *testing.T, line string)
+	}{
+		{
+			format: FormatText,
+			check: func(t *testing.T, line string) {
+				if !strings.Contains(line, "[INFO] hello world requestId=abc status=200") {
+					t.Errorf("Unexpected text line: %q", line)
+				}
+			},
+		},
+		{
+			format: FormatLogfmt,
+			check: func(t *testing.T, line string) {
+				if !strings.Contains(line, `msg="hello world" requestId=abc status=200`) {
+					t.Errorf("Unexpected logfmt line: %q", line)
+				}
+			},
+		},
+		{
+			format: FormatJSON,
+			check: func(t *testing.T, line string) {
+				var entry map[string]interface{}
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("Line is not JSON: %v", err)
+				}
+				if entry["msg"] != "hello world" || entry["requestId"] != "abc" || entry["status"] != float64(200) {
+					t.Errorf("Unexpected JSON entry: %v", entry)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := NewWithFormat(INFO, tt.format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.With("requestId", "abc").Log(INFO, "hello world", "status", 200)
+			tt.check(t, strings.TrimSpace(buf.String()))
+		})
+	}
+}
+
+func TestTextEscapesControlCharacters(t *testing.T) {
+	var buf bytes.Buffer
+	l, _ := NewWithFormat(INFO, FormatText, &buf)
+
+	l.With("requestId", "a\nb").Log(INFO, "path /x\r\n[ERROR] forged", "agent", "curl\t1")
+	line := buf.String()
+	if strings.Count(line, "\n") != 1 || strings.Contains(line, "\r") {
+		t.Fatalf("line breaks were written unescaped: %q", line)
+	}
+	for _, want := range []string{`path /x\r\n[ERROR] forged`, `requestId="a\nb"`, `agent="curl\t1"`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("line %q does not contain %s", line, want)
+		}
+	}
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	l, _ := NewWithFormat(WARN, FormatText, &buf)
+	derived := l.With("requestId", "abc")
+
+	derived.Info("dropped %d", 1)
+	if buf.Len() != 0 {
+		t.Errorf("Expected info to be filtered, got %q", buf.String())
+	}
+
+	// Derived loggers follow level changes of their parent
+	l.SetLevel(DEBUG)
+	derived.Debug("kept %d", 2)
+	if !strings.Contains(buf.String(), "kept 2") {
+		t.Errorf("Expected debug line, got %q", buf.String())
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewWithFormat(INFO, "xml", &bytes.Buffer{}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}